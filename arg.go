@@ -0,0 +1,362 @@
+package gi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArgType QueryArg、PathArg 支持的类型
+type ArgType interface {
+	int | int8 | int16 | int32 | int64 |
+		uint | uint8 | uint16 | uint32 | uint64 |
+		float32 | float64 | bool | string |
+		time.Time | time.Duration | uuid.UUID
+}
+
+// 参数错误时 FieldError 的 Tag
+const (
+	argTagRequired = "required"
+	argTagMin      = "min"
+	argTagMax      = "max"
+	argTagOneOf    = "oneof"
+)
+
+// defaultTimeLayouts time.Time 参数默认依次尝试的格式
+var defaultTimeLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+// ArgOpt QueryArg、PathArg 的配置项
+type ArgOpt[T ArgType] func(*argConfig[T])
+
+type argConfig[T ArgType] struct {
+	def      *T
+	defs     *[]T // QueryArgs 的默认值
+	min, max *T
+	enum     []T
+	layouts  []string
+	loc      *time.Location
+}
+
+// ArgWithDefault 参数可选，没有或为空时取 v；未指定时参数必填
+func ArgWithDefault[T ArgType](v T) ArgOpt[T] {
+	return func(cfg *argConfig[T]) {
+		cfg.def = &v
+	}
+}
+
+// ArgWithDefaults QueryArgs 的参数可选，没有或为空时取 l，l 可以为空；优先于 ArgWithDefault
+func ArgWithDefaults[T ArgType](l ...T) ArgOpt[T] {
+	return func(cfg *argConfig[T]) {
+		cfg.defs = &l
+	}
+}
+
+// ArgWithMin 最小值（含），默认值不检查
+func ArgWithMin[T ArgType](v T) ArgOpt[T] {
+	return func(cfg *argConfig[T]) {
+		cfg.min = &v
+	}
+}
+
+// ArgWithMax 最大值（含），默认值不检查
+func ArgWithMax[T ArgType](v T) ArgOpt[T] {
+	return func(cfg *argConfig[T]) {
+		cfg.max = &v
+	}
+}
+
+// ArgWithEnum 只能取 l 中的值
+func ArgWithEnum[T ArgType](l ...T) ArgOpt[T] {
+	return func(cfg *argConfig[T]) {
+		cfg.enum = l
+	}
+}
+
+// ArgWithLayouts time.Time 参数依次尝试的格式，默认为 RFC3339、2006-01-02 15:04:05、2006-01-02
+func ArgWithLayouts(layouts ...string) ArgOpt[time.Time] {
+	return func(cfg *argConfig[time.Time]) {
+		cfg.layouts = layouts
+	}
+}
+
+// ArgWithLocation time.Time 参数不含时区时使用的时区，默认为 time.Local
+func ArgWithLocation(loc *time.Location) ArgOpt[time.Time] {
+	return func(cfg *argConfig[time.Time]) {
+		cfg.loc = loc
+	}
+}
+
+func newArgConfig[T ArgType](opt []ArgOpt[T]) *argConfig[T] {
+	cfg := &argConfig[T]{
+		layouts: defaultTimeLayouts,
+		loc:     time.Local,
+	}
+	for _, v := range opt {
+		v(cfg)
+	}
+	return cfg
+}
+
+// QueryArg 取 query 参数并转换为 T，失败时以 400 输出参数名及原因，如 "page应为整数"
+//
+//	page, ok := gi.QueryArg(c, "page", gi.ArgWithDefault(1), gi.ArgWithMin(1))
+//	status, ok := gi.QueryArg(c, "status", gi.ArgWithEnum("paid", "refunded"))
+func QueryArg[T ArgType](c *gin.Context, key string, opt ...ArgOpt[T]) (T, bool) {
+	s, _ := c.GetQuery(key)
+	return parseArg(c, key, s, newArgConfig(opt))
+}
+
+// QueryArgs 取多值 query 参数，支持 ids=1&ids=2 及 ids=1,2 两种形式，每个值的规则与 QueryArg 相同
+// 有 ArgWithDefaults 时参数可选，没有时返回其中的值；有 ArgWithDefault 时参数可选，没有时返回只含该值的 slice
+func QueryArgs[T ArgType](c *gin.Context, key string, opt ...ArgOpt[T]) ([]T, bool) {
+	cfg := newArgConfig(opt)
+
+	var l []string
+	for _, v := range c.QueryArray(key) {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				l = append(l, s)
+			}
+		}
+	}
+	if len(l) == 0 {
+		if cfg.defs != nil {
+			return slices.Clone(*cfg.defs), true
+		}
+		if cfg.def != nil {
+			return []T{*cfg.def}, true
+		}
+		abortArg(c, key, "", argTagRequired, "", message(GetLocale(c), msgArgRequired, argLabel(c, key)))
+		return nil, false
+	}
+
+	ret := make([]T, 0, len(l))
+	for _, s := range l {
+		v, ok := parseArg(c, key, s, cfg)
+		if !ok {
+			return nil, false
+		}
+		ret = append(ret, v)
+	}
+	return ret, true
+}
+
+// PathArg 取路径参数并转换为 T，规则与 QueryArg 相同
+func PathArg[T ArgType](c *gin.Context, key string, opt ...ArgOpt[T]) (T, bool) {
+	return parseArg(c, key, c.Param(key), newArgConfig(opt))
+}
+
+func parseArg[T ArgType](c *gin.Context, key, s string, cfg *argConfig[T]) (T, bool) {
+	var zero T
+	locale := GetLocale(c)
+	label := argLabel(c, key)
+
+	if s == "" {
+		if cfg.def != nil {
+			return *cfg.def, true
+		}
+		abortArg(c, key, s, argTagRequired, "", message(locale, msgArgRequired, label))
+		return zero, false
+	}
+
+	v, err := convertArg[T](s, cfg)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			abortArg(c, key, s, bindErrTagRange, "", message(locale, msgOutOfRange, label))
+		} else {
+			kind := argKindMessage(zero)
+			abortArg(c, key, s, bindErrTagType, strings.TrimPrefix(kind, "gi_kind_"),
+				message(locale, msgTypeMismatch, label, message(locale, kind, strings.Join(cfg.layouts, " / "))))
+		}
+		return zero, false
+	}
+
+	if cfg.min != nil && compareArg(v, *cfg.min) < 0 {
+		p := formatArg(*cfg.min, cfg)
+		abortArg(c, key, s, argTagMin, p, message(locale, msgArgMin, label, p))
+		return zero, false
+	}
+	if cfg.max != nil && compareArg(v, *cfg.max) > 0 {
+		p := formatArg(*cfg.max, cfg)
+		abortArg(c, key, s, argTagMax, p, message(locale, msgArgMax, label, p))
+		return zero, false
+	}
+	if len(cfg.enum) > 0 && !slices.ContainsFunc(cfg.enum, func(e T) bool { return compareArg(v, e) == 0 }) {
+		l := make([]string, 0, len(cfg.enum))
+		for _, e := range cfg.enum {
+			l = append(l, formatArg(e, cfg))
+		}
+		p := strings.Join(l, " ")
+		abortArg(c, key, s, argTagOneOf, p, message(locale, msgArgOneOf, label, p))
+		return zero, false
+	}
+
+	return v, true
+}
+
+// convertArg 按 T 的类型转换，数值超出范围时返回的错误为 strconv.ErrRange；浮点数不接受 NaN、Inf
+func convertArg[T ArgType](s string, cfg *argConfig[T]) (T, error) {
+	var ret T
+	switch p := any(&ret).(type) {
+	case *string:
+		*p = s
+	case *bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return ret, err
+		}
+		*p = v
+	case *time.Time:
+		v, err := parseTime(s, cfg.layouts, cfg.loc)
+		if err != nil {
+			return ret, err
+		}
+		*p = v
+	case *time.Duration:
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return ret, err
+		}
+		*p = v
+	case *uuid.UUID:
+		v, err := uuid.Parse(s)
+		if err != nil {
+			return ret, err
+		}
+		*p = v
+	default:
+		rv := reflect.ValueOf(p).Elem()
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+			if err != nil {
+				return ret, err
+			}
+			rv.SetInt(v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+			if err != nil {
+				return ret, err
+			}
+			rv.SetUint(v)
+		case reflect.Float32, reflect.Float64:
+			v, err := strconv.ParseFloat(s, rv.Type().Bits())
+			if err != nil {
+				return ret, err
+			}
+			// ParseFloat 接受 NaN、Inf，NaN 与任何值比较都为 false，会绕过 ArgWithMin、ArgWithMax
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return ret, errors.Newf("invalid number %q", s)
+			}
+			rv.SetFloat(v)
+		}
+	}
+	return ret, nil
+}
+
+// parseTime 依次尝试 layouts，格式中不含时区时使用 loc
+func parseTime(s string, layouts []string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// compareArg 比较两个参数值，bool 中 false 小于 true
+func compareArg[T ArgType](a, b T) int {
+	switch x := any(a).(type) {
+	case time.Time:
+		return x.Compare(any(b).(time.Time))
+	case uuid.UUID:
+		return strings.Compare(x.String(), any(b).(uuid.UUID).String())
+	case string:
+		return strings.Compare(x, any(b).(string))
+	case bool:
+		y := any(b).(bool)
+		switch {
+		case x == y:
+			return 0
+		case y:
+			return -1
+		}
+		return 1
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(va.Uint(), vb.Uint())
+	}
+	return compareOrdered(va.Float(), vb.Float())
+}
+
+func compareOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// formatArg 提示中参数值的格式，时间使用第一个格式
+func formatArg[T ArgType](v T, cfg *argConfig[T]) string {
+	if t, ok := any(v).(time.Time); ok && len(cfg.layouts) > 0 {
+		return t.Format(cfg.layouts[0])
+	}
+	return fmt.Sprint(v)
+}
+
+// argKindMessage 期望类型的提示 key
+func argKindMessage[T ArgType](v T) string {
+	switch any(v).(type) {
+	case time.Time:
+		return msgKindTime
+	case time.Duration:
+		return msgKindDuration
+	case uuid.UUID:
+		return msgKindUUID
+	}
+	return kindMessage(reflect.TypeOf(v))
+}
+
+// argLabel 参数在提示中的显示名，可通过 RegisterLabels 注册
+func argLabel(c *gin.Context, key string) string {
+	if label := catalogLabel(GetLocale(c), key); label != "" {
+		return label
+	}
+	return key
+}
+
+// abortArg 以 400 输出参数错误，格式与绑定错误一致
+func abortArg(c *gin.Context, key, value, tag, param, msg string) {
+	log.WithField("key", key).
+		WithField("value", value).
+		WithField("requestId", GetRequestId(c)).
+		Warnln("bad arg")
+
+	fe := FieldError{
+		Field:    key,
+		JsonPath: key,
+		Tag:      tag,
+		Param:    param,
+		Message:  msg,
+	}
+	renderError(c, fieldsCusError(errors.Newf("bad arg %s: %q", key, value), []FieldError{fe}))
+}
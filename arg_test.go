@@ -0,0 +1,201 @@
+package gi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// argContext 以 query 构造请求，返回 gin.Context 及响应
+func argContext(query string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	c.Request.Header.Set("Accept", "application/json")
+	return c, w
+}
+
+// argFieldError 响应中的第一个 FieldError
+func argFieldError(t *testing.T, w *httptest.ResponseRecorder) FieldError {
+	t.Helper()
+	var p struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || len(p.Errors) == 0 {
+		t.Fatalf("no field error in %s", w.Body.String())
+	}
+	return p.Errors[0]
+}
+
+func TestQueryArgFloat(t *testing.T) {
+	cases := []struct {
+		query string
+		want  float64
+		tag   string // 为空时应成功
+	}{
+		{"x=1.5", 1.5, ""},
+		{"x=0", 0, ""},
+		{"x=NaN", 0, bindErrTagType},
+		{"x=nan", 0, bindErrTagType},
+		{"x=Inf", 0, bindErrTagType},
+		{"x=%2BInf", 0, bindErrTagType},
+		{"x=-Infinity", 0, bindErrTagType},
+		{"x=1e400", 0, bindErrTagRange},
+		{"x=abc", 0, bindErrTagType},
+		{"x=-1", 0, argTagMin},
+		{"x=101", 0, argTagMax},
+	}
+	for _, tc := range cases {
+		c, w := argContext(tc.query)
+		v, ok := QueryArg(c, "x", ArgWithMin(0.0), ArgWithMax(100.0))
+		if tc.tag == "" {
+			if !ok || v != tc.want {
+				t.Errorf("%s: got %v %v, want %v", tc.query, v, ok, tc.want)
+			}
+			continue
+		}
+		if ok {
+			t.Errorf("%s: got %v, want error %s", tc.query, v, tc.tag)
+			continue
+		}
+		if fe := argFieldError(t, w); fe.Tag != tc.tag || w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d tag %q, want 400 %q", tc.query, w.Code, fe.Tag, tc.tag)
+		}
+	}
+}
+
+func TestQueryArgRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		parse func(c *gin.Context) (any, bool)
+		want  any
+		fe    FieldError // Tag 为空时应成功
+	}{
+		{
+			name: "default", query: "",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "page", ArgWithDefault(1)) },
+			want:  1,
+		},
+		{
+			name: "required", query: "",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[int](c, "page") },
+			fe:    FieldError{Tag: argTagRequired, Message: "缺少参数page"},
+		},
+		{
+			name: "min", query: "page=0",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "page", ArgWithMin(1)) },
+			fe:    FieldError{Tag: argTagMin, Param: "1", Message: "page不能小于1"},
+		},
+		{
+			name: "max", query: "size=101",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "size", ArgWithMax(100)) },
+			fe:    FieldError{Tag: argTagMax, Param: "100", Message: "size不能大于100"},
+		},
+		{
+			name: "enum", query: "status=paid",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "status", ArgWithEnum("paid", "refunded")) },
+			want:  "paid",
+		},
+		{
+			name: "enum rejected", query: "status=x",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "status", ArgWithEnum("paid", "refunded")) },
+			fe:    FieldError{Tag: argTagOneOf, Param: "paid refunded", Message: "status必须是[paid refunded]中的一个"},
+		},
+		{
+			name: "int type", query: "page=a",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[int](c, "page") },
+			fe:    FieldError{Tag: bindErrTagType, Param: "integer", Message: "page应为整数"},
+		},
+		{
+			name: "int8 range", query: "n=128",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[int8](c, "n") },
+			fe:    FieldError{Tag: bindErrTagRange, Message: "n超出范围"},
+		},
+		{
+			name: "layouts", query: "day=2024%2F01%2F02",
+			parse: func(c *gin.Context) (any, bool) {
+				return QueryArg(c, "day", ArgWithLayouts("2006/01/02"), ArgWithLocation(time.UTC))
+			},
+			want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "layouts rejected", query: "day=2024-01-02",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg(c, "day", ArgWithLayouts("2006/01/02")) },
+			fe:    FieldError{Tag: bindErrTagType, Param: "time", Message: "day应为时间，格式为 2006/01/02"},
+		},
+		{
+			name: "time min", query: "day=2023-12-31",
+			parse: func(c *gin.Context) (any, bool) {
+				return QueryArg(c, "day", ArgWithMin(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)), ArgWithLayouts(time.DateOnly))
+			},
+			fe: FieldError{Tag: argTagMin, Param: "2024-01-01", Message: "day不能小于2024-01-01"},
+		},
+		{
+			name: "duration", query: "ttl=1h30m",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[time.Duration](c, "ttl") },
+			want:  90 * time.Minute,
+		},
+		{
+			name: "uuid rejected", query: "id=x",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[uuid.UUID](c, "id") },
+			fe:    FieldError{Tag: bindErrTagType, Param: "uuid", Message: "id应为UUID"},
+		},
+		{
+			name: "bool", query: "on=true",
+			parse: func(c *gin.Context) (any, bool) { return QueryArg[bool](c, "on") },
+			want:  true,
+		},
+	}
+	for _, tc := range cases {
+		c, w := argContext(tc.query)
+		v, ok := tc.parse(c)
+		if tc.fe.Tag == "" {
+			if !ok || v != tc.want {
+				t.Errorf("%s: got %v %v, want %v", tc.name, v, ok, tc.want)
+			}
+			continue
+		}
+		if ok {
+			t.Errorf("%s: got %v, want error", tc.name, v)
+			continue
+		}
+		fe := argFieldError(t, w)
+		fe.Field, fe.JsonPath = "", ""
+		if fe != tc.fe {
+			t.Errorf("%s: got %+v, want %+v", tc.name, fe, tc.fe)
+		}
+	}
+}
+
+func TestQueryArgs(t *testing.T) {
+	cases := []struct {
+		query string
+		opt   []ArgOpt[int]
+		want  []int
+		ok    bool
+	}{
+		{"ids=1&ids=2", nil, []int{1, 2}, true},
+		{"ids=1,2,%203", nil, []int{1, 2, 3}, true},
+		{"", nil, nil, false},
+		{"", []ArgOpt[int]{ArgWithDefault(7)}, []int{7}, true},
+		{"", []ArgOpt[int]{ArgWithDefaults(1, 2)}, []int{1, 2}, true},
+		{"", []ArgOpt[int]{ArgWithDefaults[int]()}, []int{}, true},
+		{"ids=1,x", nil, nil, false},
+		{"ids=1,5", []ArgOpt[int]{ArgWithMax(3)}, nil, false},
+	}
+	for _, tc := range cases {
+		c, _ := argContext(tc.query)
+		got, ok := QueryArgs(c, "ids", tc.opt...)
+		if ok != tc.ok || !slices.Equal(got, tc.want) {
+			t.Errorf("%q: got %v %v, want %v %v", tc.query, got, ok, tc.want, tc.ok)
+		}
+	}
+}
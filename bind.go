@@ -0,0 +1,266 @@
+package gi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+)
+
+// Bind 将 uri、query、header 及请求体绑定到一个 T 类型的结构体，全部解码后校验一次，失败时输出错误
+// 同一字段在多处出现时，优先级由高到低为 uri、query、header、请求体，分别取 uri、form、header tag 及请求体格式对应的 tag（如 json）
+// uri、query、header 只写入带有对应 tag 的字段，没有 tag 的字段不会被覆盖
+// GET、HEAD 请求及没有请求体时跳过请求体；各处都没有的字段取 default tag 的值，见 applyDefaults
+// 各处的解码错误与校验错误一并输出，见 FieldError
+// 校验通过后，T 实现了 ValidatorCtx 或 Validator 时自动调用。T 须为结构体
+//
+//	type GetOrderReq struct {
+//		Id    int    `uri:"id" binding:"required"`
+//		Token string `header:"X-Token"`
+//		Page  int    `form:"page"`
+//	}
+//	req, ok := gi.Bind[GetOrderReq](c)
+//	if !ok {
+//		return
+//	}
+func Bind[T any](c *gin.Context) (T, bool) {
+	var obj T
+	ptr := &obj
+
+	applyDefaults(ptr)
+	skipValidation.Store(ptr, struct{}{})
+	fields, errs, ok := bindSources(c, ptr)
+	skipValidation.Delete(ptr)
+	if !ok {
+		return obj, false
+	}
+
+	if binding.Validator != nil {
+		err := binding.Validator.ValidateStruct(ptr)
+		var verrs validator.ValidationErrors
+		switch {
+		case errors.As(err, &verrs):
+			errs = append(errs, err)
+			for _, v := range TranslateFields(verrs, TransWithLocale(GetLocale(c)), TransWithBinding(ptr, nil)) {
+				// 解码失败的字段已报告，不再重复报告校验错误
+				if slices.ContainsFunc(fields, func(f FieldError) bool { return f.Field != "" && f.Field == v.Field }) {
+					continue
+				}
+				v.Message = lowerGoName(v)
+				fields = append(fields, v)
+			}
+		case err != nil:
+			abortBindError(c, err, ptr, nil)
+			return obj, false
+		}
+	}
+
+	if len(fields) > 0 {
+		err := errors.Join(errs...)
+		log.WithError(err).
+			WithField("requestId", GetRequestId(c)).
+			Errorln("bind error")
+
+		renderError(c, fieldsCusError(err, fields))
+		return obj, false
+	}
+
+	return obj, validObj(c, ptr)
+}
+
+// bindSource 一处参数来源
+type bindSource struct {
+	b    namedBinding
+	bind func() error
+}
+
+// bindSources 按优先级由低到高依次解码，后解码的覆盖先解码的
+// 返回各处可定位到字段的解码错误；其它错误（如请求体不是有效的 JSON）直接输出，ok 为 false
+func bindSources(c *gin.Context, ptr any) (fields []FieldError, errs []error, ok bool) {
+	var l []bindSource
+	if hasBody(c.Request) {
+		b := strictBinding(c, binding.Default(c.Request.Method, c.ContentType()))
+		if b == binding.Form {
+			// binding.Form 读取的 req.Form 含 query，会按 Go 字段名写入没有 form tag 的字段，请求体只取 PostForm
+			b = binding.FormPost
+		}
+		l = append(l, bindSource{b, func() error { return b.Bind(c.Request, ptr) }})
+	}
+	t := reflect.TypeOf(ptr).Elem()
+	l = append(l,
+		bindSource{binding.Header, func() error {
+			names := taggedNames(t, "header", http.CanonicalHeaderKey)
+			req := *c.Request
+			req.Header = http.Header{}
+			for k, v := range c.Request.Header {
+				if names[http.CanonicalHeaderKey(k)] {
+					req.Header[k] = v
+				}
+			}
+			has := func(name string) bool { return len(req.Header.Values(name)) > 0 }
+			return bindTagged(ptr, "header", has, func(tmp any) error { return binding.Header.Bind(&req, tmp) })
+		}},
+		bindSource{Query, func() error {
+			names := taggedNames(t, "form", nil)
+			q, present := url.Values{}, map[string]bool{}
+			for k, v := range c.Request.URL.Query() {
+				if base, _, _ := strings.Cut(k, "["); names[base] {
+					q[k] = v
+					present[base] = true
+				}
+			}
+			has := func(name string) bool { return present[name] }
+			return bindTagged(ptr, "form", has, func(tmp any) error { return DecodeQuery(q, tmp) })
+		}},
+		bindSource{binding.Uri, func() error {
+			names := taggedNames(t, "uri", nil)
+			m := make(map[string][]string, len(c.Params))
+			for _, v := range c.Params {
+				if names[v.Key] {
+					m[v.Key] = []string{v.Value}
+				}
+			}
+			has := func(name string) bool { return len(m[name]) > 0 }
+			return bindTagged(ptr, "uri", has, func(tmp any) error { return binding.Uri.BindUri(m, tmp) })
+		}},
+	)
+
+	for _, v := range l {
+		err := v.bind()
+		if err == nil {
+			continue
+		}
+
+		// binding.Validator 不是 defaultValidator 时各处解码后仍会校验，忽略，最后统一校验
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			continue
+		}
+
+		ce := bindErrorCusError(c, err, ptr, v.b)
+		if ce == nil || len(ce.Fields()) == 0 {
+			log.WithError(err).
+				WithField("requestId", GetRequestId(c)).
+				Errorln("bind error")
+
+			if ce != nil {
+				renderError(c, ce)
+			} else {
+				abortBadRequest(c, err, err.Error())
+			}
+			return nil, nil, false
+		}
+		fields = append(fields, ce.Fields()...)
+		errs = append(errs, err)
+	}
+	return fields, errs, true
+}
+
+// bindTagged header、query、uri 只写入带有 key tag 的字段
+// gin 对没有 tag 的字段按 Go 字段名取值，直接绑定到 ptr 时客户端可以通过 header、query 覆盖只带 json tag 的请求体字段（如 UserId）
+// 因此先解码到同类型的临时对象，再把带 tag 且请求中存在的字段复制到 ptr；请求中不存在但 tag 带 default= 的字段仅在 ptr 中为零值时复制
+func bindTagged(ptr any, key string, has func(name string) bool, bind func(tmp any) error) error {
+	tmp := reflect.New(reflect.TypeOf(ptr).Elem())
+	skipValidation.Store(tmp.Interface(), struct{}{})
+	defer skipValidation.Delete(tmp.Interface())
+
+	if err := bind(tmp.Interface()); err != nil {
+		return err
+	}
+	copyTagged(reflect.ValueOf(ptr).Elem(), tmp.Elem(), key, has)
+	return nil
+}
+
+// copyTagged 见 bindTagged，与 gin 一致，递归进入没有 tag 的结构体字段，返回是否复制了任一字段
+func copyTagged(dst, src reflect.Value, key string, has func(name string) bool) bool {
+	copied := false
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag, opts, _ := strings.Cut(f.Tag.Get(key), ",")
+		if tag == "-" {
+			continue
+		}
+		dv, sv := dst.Field(i), src.Field(i)
+
+		if tag != "" {
+			if !dv.CanSet() {
+				continue
+			}
+			if has(tag) || strings.Contains(opts, "default=") && dv.IsZero() && !sv.IsZero() {
+				dv.Set(sv)
+				copied = true
+			}
+			continue
+		}
+
+		st := indirectType(f.Type)
+		if st.Kind() != reflect.Struct || isQueryScalar(st) {
+			continue
+		}
+		if f.Type.Kind() != reflect.Ptr {
+			copied = copyTagged(dv, sv, key, has) || copied
+			continue
+		}
+		if sv.IsNil() || !dv.CanSet() {
+			continue
+		}
+		if !dv.IsNil() {
+			copied = copyTagged(dv.Elem(), sv.Elem(), key, has) || copied
+			continue
+		}
+		p := reflect.New(st)
+		if copyTagged(p.Elem(), sv.Elem(), key, has) {
+			dv.Set(p)
+			copied = true
+		}
+	}
+	return copied
+}
+
+// taggedNames t 中带 key tag 的字段名，递归进入没有 tag 的结构体字段；normalize 不为 nil 时转换名称，如 header 的规范格式
+func taggedNames(t reflect.Type, key string, normalize func(string) string) map[string]bool {
+	names := map[string]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			if name := tagName(f, key); name != "" {
+				if normalize != nil {
+					name = normalize(name)
+				}
+				names[name] = true
+				continue
+			}
+			if f.Tag.Get(key) == "-" {
+				continue
+			}
+			if st := indirectType(f.Type); st.Kind() == reflect.Struct && !isQueryScalar(st) {
+				walk(st)
+			}
+		}
+	}
+	walk(indirectType(t))
+	return names
+}
+
+// hasBody GET、HEAD 请求及 Content-Length 为 0 时视为没有请求体
+func hasBody(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return false
+	}
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
@@ -0,0 +1,113 @@
+package gi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindTestReq struct {
+	Id     int    `uri:"id"`
+	Token  string `header:"X-Token"`
+	Page   int    `form:"page"`
+	Name   string `json:"name"`
+	UserId int    `json:"user_id"`
+}
+
+func TestBindUntaggedFieldsNotOverridden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	New()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/orders/7?page=2&Name=fromquery&UserId=99&user_id=98", strings.NewReader(`{"name":"body","user_id":1}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("X-Token", "t")
+	c.Request.Header.Set("Name", "fromheader")
+	c.Request.Header.Set("UserId", "97")
+	c.Params = gin.Params{{Key: "id", Value: "7"}, {Key: "Name", Value: "fromuri"}}
+
+	req, ok := Bind[bindTestReq](c)
+	if !ok {
+		t.Fatalf("Bind failed: %d %s", w.Code, w.Body.String())
+	}
+	want := bindTestReq{Id: 7, Token: "t", Page: 2, Name: "body", UserId: 1}
+	if req != want {
+		t.Errorf("Bind = %+v, want %+v", req, want)
+	}
+}
+
+func TestBindTaggedFieldsOverrideBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	New()
+
+	type overrideReq struct {
+		Page int `json:"page" form:"page"`
+		Size int `json:"size" form:"size"`
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/?page=3", strings.NewReader(`{"page":1,"size":20}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	req, ok := Bind[overrideReq](c)
+	if !ok {
+		t.Fatalf("Bind failed: %d %s", w.Code, w.Body.String())
+	}
+	// size 不在 query 中，保留请求体的值
+	if req.Page != 3 || req.Size != 20 {
+		t.Errorf("Bind = %+v, want {Page:3 Size:20}", req)
+	}
+}
+
+func TestBindFormBodyIgnoresQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	New()
+
+	type formReq struct {
+		Name   string `form:"name"`
+		UserId int    `json:"user_id"`
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/x?UserId=99", strings.NewReader("name=a"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	req, ok := Bind[formReq](c)
+	if !ok {
+		t.Fatalf("Bind failed: %d %s", w.Code, w.Body.String())
+	}
+	if req.Name != "a" || req.UserId != 0 {
+		t.Errorf("Bind = %+v, want {Name:a UserId:0}", req)
+	}
+}
+
+func TestShouldBindQueryMap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	New()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?a=1", nil)
+
+	// map 不可作为 sync.Map 的 key，校验前不能 panic
+	m := map[string]string{}
+	if err := c.ShouldBindQuery(&m); err != nil {
+		t.Fatalf("ShouldBindQuery(&m): %v", err)
+	}
+	if m["a"] != "1" {
+		t.Errorf("ShouldBindQuery(&m) = %v, want a=1", m)
+	}
+
+	m2 := map[string]string{}
+	if err := c.ShouldBindQuery(m2); err != nil {
+		t.Fatalf("ShouldBindQuery(m2): %v", err)
+	}
+	if m2["a"] != "1" {
+		t.Errorf("ShouldBindQuery(m2) = %v, want a=1", m2)
+	}
+}
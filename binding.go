@@ -16,9 +16,19 @@ type defaultValidator struct {
 
 var _ binding.StructValidator = &defaultValidator{}
 
+// skipValidation 正在分步绑定的对象，gin 的各个 binding 解码后不校验，由调用方在全部解码完成后校验一次，见 Bind
+var skipValidation sync.Map
+
 // ValidateStruct receives any kind of type, but only performed struct or pointer to struct type.
 func (v *defaultValidator) ValidateStruct(obj interface{}) error {
 	value := reflect.ValueOf(obj)
+	// 只有指针可作为 sync.Map 的 key，map 等不可比较的类型会 panic
+	if value.Kind() == reflect.Ptr {
+		if _, ok := skipValidation.Load(obj); ok {
+			return nil
+		}
+	}
+
 	valueType := value.Kind()
 	if valueType == reflect.Ptr {
 		valueType = value.Elem().Kind()
@@ -45,5 +55,7 @@ func (v *defaultValidator) lazyinit() {
 	v.once.Do(func() {
 		v.validate = validator.New()
 		v.validate.SetTagName("binding")
+		v.validate.RegisterTagNameFunc(validatorTagName)
+		applyValidations(v.validate)
 	})
 }
@@ -0,0 +1,144 @@
+package gi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const defaultTag = "default"
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+// applyDefaults 在解码之前为零值字段设置 default tag 中的值，解码时请求中有的字段会被覆盖，没有的保留默认值
+// 支持数值、string、bool、time.Duration（如 30s）、time.Time（格式取 time_format tag，默认同 QueryArg）、
+// 指针及 slice（以逗号分隔，如 default:"1,2"）；嵌套的结构体会递归处理
+// 解码时创建的结构体不处理，包括 slice 中的结构体及解码前为 nil 的结构体指针（如 {"filter":{}} 中的 filter）：
+// 解码后无法区分其中的零值是请求传入的还是缺省的，需要默认值时使用非指针的结构体字段，或在解码前分配好指针
+// default tag 的值无法转换时 panic，与 validator 对错误 tag 的处理一致
+//
+//	type ListReq struct {
+//		Page     int      `form:"page" default:"1"`
+//		PageSize int      `form:"page_size" default:"20" binding:"max=100"`
+//		Status   []string `form:"status" default:"paid,shipped"`
+//	}
+func applyDefaults(obj any) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	applyStructDefaults(v.Elem())
+}
+
+func applyStructDefaults(v reflect.Value) {
+	if v.Kind() != reflect.Struct || v.Type() == timeType {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		fv := v.Field(i)
+		// 未导出的嵌入结构体本身不可设置，其导出字段可以
+		if fld.Anonymous && !fld.IsExported() && fv.Kind() == reflect.Struct {
+			applyStructDefaults(fv)
+			continue
+		}
+		if !fv.CanSet() {
+			continue
+		}
+
+		s, ok := fld.Tag.Lookup(defaultTag)
+		if !ok {
+			if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				applyStructDefaults(fv.Elem())
+			} else {
+				applyStructDefaults(fv)
+			}
+			continue
+		}
+		if !fv.IsZero() {
+			continue
+		}
+		if err := setDefault(fv, s, fld); err != nil {
+			panic(errors.Wrapf(err, "gi: bad default tag on %s.%s", t.Name(), fld.Name))
+		}
+	}
+}
+
+// setDefault 将 s 转换后设置到 v
+func setDefault(v reflect.Value, s string, fld reflect.StructField) error {
+	switch v.Type() {
+	case timeType:
+		layouts := defaultTimeLayouts
+		if f := fld.Tag.Get("time_format"); f != "" {
+			layouts = []string{f}
+		}
+		t, err := parseTime(s, layouts, time.Local)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setDefault(p.Elem(), s, fld); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		l := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setDefault(l.Index(i), strings.TrimSpace(part), fld); err != nil {
+				return err
+			}
+		}
+		v.Set(l)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.Newf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package gi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type defaultFilter struct {
+	Status string `json:"status" default:"paid"`
+	Limit  int    `json:"limit" default:"10"`
+}
+
+type defaultReq struct {
+	Page    int            `json:"page" default:"1"`
+	Filter  defaultFilter  `json:"filter"`
+	PFilter *defaultFilter `json:"p_filter"`
+	Items   []defaultFilter
+}
+
+func TestApplyDefaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	New()
+
+	cases := []struct {
+		name  string
+		body  string
+		init  func(*defaultReq)
+		check func(t *testing.T, req defaultReq)
+	}{
+		{
+			name: "missing fields use defaults",
+			body: `{"filter":{"limit":5}}`,
+			check: func(t *testing.T, req defaultReq) {
+				if req.Page != 1 || req.Filter.Status != "paid" || req.Filter.Limit != 5 {
+					t.Errorf("got %+v", req)
+				}
+			},
+		},
+		{
+			name: "explicit zero kept",
+			body: `{"page":0,"filter":{"limit":0}}`,
+			check: func(t *testing.T, req defaultReq) {
+				// 顶层字段在解码前已设置默认值，请求中的 0 覆盖默认值
+				if req.Page != 0 || req.Filter.Limit != 0 {
+					t.Errorf("got %+v", req)
+				}
+			},
+		},
+		{
+			name: "pointer allocated by decoder is not defaulted",
+			body: `{"p_filter":{},"Items":[{}]}`,
+			check: func(t *testing.T, req defaultReq) {
+				if req.PFilter == nil || *req.PFilter != (defaultFilter{}) {
+					t.Errorf("p_filter = %+v, want zero value", req.PFilter)
+				}
+				if len(req.Items) != 1 || req.Items[0] != (defaultFilter{}) {
+					t.Errorf("items = %+v, want one zero value", req.Items)
+				}
+			},
+		},
+		{
+			name: "pointer allocated before decoding is defaulted",
+			body: `{"p_filter":{"limit":3}}`,
+			init: func(req *defaultReq) { req.PFilter = &defaultFilter{} },
+			check: func(t *testing.T, req defaultReq) {
+				if req.PFilter == nil || *req.PFilter != (defaultFilter{Status: "paid", Limit: 3}) {
+					t.Errorf("p_filter = %+v", req.PFilter)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		var req defaultReq
+		if tc.init != nil {
+			tc.init(&req)
+		}
+		if !(&BaseHdl{}).Binding(c, &req) {
+			t.Fatalf("%s: Binding failed: %s", tc.name, w.Body.String())
+		}
+		t.Run(tc.name, func(t *testing.T) { tc.check(t, req) })
+	}
+}
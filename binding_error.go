@@ -0,0 +1,547 @@
+package gi
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	unit "github.com/go-playground/universal-translator"
+	"google.golang.org/protobuf/proto"
+)
+
+// gi 自身的提示，与校验提示注册在同一翻译器中
+const (
+	msgTypeMismatch = "gi_type_mismatch" // {0} 字段，{1} 期望的类型
+	msgOutOfRange   = "gi_out_of_range"  // {0} 字段
+	msgJsonSyntax   = "gi_json_syntax"   // {0} 出错的字节位置
+	msgJsonEOF      = "gi_json_eof"
+	msgBodyEmpty    = "gi_body_empty"
+	msgBodyKind     = "gi_body_kind"      // {0} 期望的类型
+	msgBodyInvalid  = "gi_body_invalid"   // {0} 请求体格式，如 YAML
+	msgInvalidValue = "gi_invalid_value"  // {0} 无法确定字段时出错的值
+	msgBodyTooLarge = "gi_body_too_large" // {0} 字节数上限
+	msgUnknownField = "gi_unknown_field"  // {0} 字段
+	msgDuplicateKey = "gi_duplicate_key"  // {0} 字段
+	msgMaxDepth     = "gi_max_depth"      // {0} 层级上限
+	msgTrailingData = "gi_trailing_data"
+	msgKindInteger  = "gi_kind_integer"
+	msgKindNumber   = "gi_kind_number"
+	msgKindString   = "gi_kind_string"
+	msgKindBool     = "gi_kind_bool"
+	msgKindArray    = "gi_kind_array"
+	msgKindObject   = "gi_kind_object"
+	msgKindTime     = "gi_kind_time" // {0} 可用的格式
+	msgKindDuration = "gi_kind_duration"
+	msgKindUUID     = "gi_kind_uuid"
+	msgArgRequired  = "gi_arg_required" // {0} 参数名
+	msgArgMin       = "gi_arg_min"      // {0} 参数名，{1} 最小值
+	msgArgMax       = "gi_arg_max"      // {0} 参数名，{1} 最大值
+	msgArgOneOf     = "gi_arg_oneof"    // {0} 参数名，{1} 可选值
+	bindErrTagType  = "type"            // 类型不匹配时 FieldError 的 Tag
+	bindErrTagRange = "range"           // 数值超出范围时 FieldError 的 Tag
+)
+
+var messages = map[Locale]map[string]string{
+	ZH: {
+		msgTypeMismatch: "{0}应为{1}",
+		msgOutOfRange:   "{0}超出范围",
+		msgJsonSyntax:   "请求体不是有效的 JSON（位置 {0}）",
+		msgJsonEOF:      "请求体 JSON 不完整",
+		msgBodyEmpty:    "请求体不能为空",
+		msgBodyKind:     "请求体应为{0}",
+		msgBodyInvalid:  "请求体不是有效的 {0}",
+		msgInvalidValue: "参数值{0}格式不正确",
+		msgBodyTooLarge: "请求体不能超过{0}字节",
+		msgUnknownField: "{0}为未知字段",
+		msgDuplicateKey: "{0}重复",
+		msgMaxDepth:     "请求体嵌套不能超过{0}层",
+		msgTrailingData: "请求体 JSON 之后有多余的内容",
+		msgKindInteger:  "整数",
+		msgKindNumber:   "数字",
+		msgKindString:   "字符串",
+		msgKindBool:     "布尔值",
+		msgKindArray:    "数组",
+		msgKindObject:   "对象",
+		msgKindTime:     "时间，格式为 {0}",
+		msgKindDuration: "时长，如 1h30m",
+		msgKindUUID:     "UUID",
+		msgArgRequired:  "缺少参数{0}",
+		msgArgMin:       "{0}不能小于{1}",
+		msgArgMax:       "{0}不能大于{1}",
+		msgArgOneOf:     "{0}必须是[{1}]中的一个",
+	},
+	EN: {
+		msgTypeMismatch: "{0} must be {1}",
+		msgOutOfRange:   "{0} is out of range",
+		msgJsonSyntax:   "request body is not valid JSON (offset {0})",
+		msgJsonEOF:      "request body JSON is incomplete",
+		msgBodyEmpty:    "request body must not be empty",
+		msgBodyKind:     "request body must be {0}",
+		msgBodyInvalid:  "request body is not valid {0}",
+		msgInvalidValue: "parameter value {0} is invalid",
+		msgBodyTooLarge: "request body must not exceed {0} bytes",
+		msgUnknownField: "{0} is not a known field",
+		msgDuplicateKey: "{0} is duplicated",
+		msgMaxDepth:     "request body must not be nested more than {0} levels",
+		msgTrailingData: "request body has extra data after the JSON value",
+		msgKindInteger:  "an integer",
+		msgKindNumber:   "a number",
+		msgKindString:   "a string",
+		msgKindBool:     "a boolean",
+		msgKindArray:    "an array",
+		msgKindObject:   "an object",
+		msgKindTime:     "a time in format {0}",
+		msgKindDuration: "a duration such as 1h30m",
+		msgKindUUID:     "a UUID",
+		msgArgRequired:  "{0} is required",
+		msgArgMin:       "{0} must be {1} or greater",
+		msgArgMax:       "{0} must be {1} or less",
+		msgArgOneOf:     "{0} must be one of [{1}]",
+	},
+	ZHHant: {
+		msgTypeMismatch: "{0}應為{1}",
+		msgOutOfRange:   "{0}超出範圍",
+		msgJsonSyntax:   "請求內容不是有效的 JSON（位置 {0}）",
+		msgJsonEOF:      "請求內容 JSON 不完整",
+		msgBodyEmpty:    "請求內容不能為空",
+		msgBodyKind:     "請求內容應為{0}",
+		msgBodyInvalid:  "請求內容不是有效的 {0}",
+		msgInvalidValue: "參數值{0}格式不正確",
+		msgBodyTooLarge: "請求內容不能超過{0}位元組",
+		msgUnknownField: "{0}為未知欄位",
+		msgDuplicateKey: "{0}重複",
+		msgMaxDepth:     "請求內容巢狀不能超過{0}層",
+		msgTrailingData: "請求內容 JSON 之後有多餘的內容",
+		msgKindInteger:  "整數",
+		msgKindNumber:   "數字",
+		msgKindString:   "字串",
+		msgKindBool:     "布林值",
+		msgKindArray:    "陣列",
+		msgKindObject:   "物件",
+		msgKindTime:     "時間，格式為 {0}",
+		msgKindDuration: "時長，如 1h30m",
+		msgKindUUID:     "UUID",
+		msgArgRequired:  "缺少參數{0}",
+		msgArgMin:       "{0}不能小於{1}",
+		msgArgMax:       "{0}不能大於{1}",
+		msgArgOneOf:     "{0}必須是[{1}]中的一個",
+	},
+	JA: {
+		msgTypeMismatch: "{0}は{1}でなければなりません",
+		msgOutOfRange:   "{0}が範囲外です",
+		msgJsonSyntax:   "リクエストボディが有効な JSON ではありません（位置 {0}）",
+		msgJsonEOF:      "リクエストボディの JSON が不完全です",
+		msgBodyEmpty:    "リクエストボディが空です",
+		msgBodyKind:     "リクエストボディは{0}でなければなりません",
+		msgBodyInvalid:  "リクエストボディが有効な {0} ではありません",
+		msgInvalidValue: "パラメータの値{0}が不正です",
+		msgBodyTooLarge: "リクエストボディは{0}バイト以下でなければなりません",
+		msgUnknownField: "{0}は不明なフィールドです",
+		msgDuplicateKey: "{0}が重複しています",
+		msgMaxDepth:     "リクエストボディのネストは{0}階層以下でなければなりません",
+		msgTrailingData: "リクエストボディの JSON の後に余分なデータがあります",
+		msgKindInteger:  "整数",
+		msgKindNumber:   "数値",
+		msgKindString:   "文字列",
+		msgKindBool:     "真偽値",
+		msgKindArray:    "配列",
+		msgKindObject:   "オブジェクト",
+		msgKindTime:     "日時（形式: {0}）",
+		msgKindDuration: "期間（例: 1h30m）",
+		msgKindUUID:     "UUID",
+		msgArgRequired:  "パラメータ{0}は必須です",
+		msgArgMin:       "{0}は{1}以上でなければなりません",
+		msgArgMax:       "{0}は{1}以下でなければなりません",
+		msgArgOneOf:     "{0}は[{1}]のいずれかでなければなりません",
+	},
+}
+
+// registerMessages 将 gi 自身的提示加入各语言的翻译器
+func registerMessages(u *unit.UniversalTranslator) error {
+	for _, locale := range supportedLocales {
+		trans, ok := u.GetTranslator(string(locale))
+		if !ok {
+			continue
+		}
+		for k, v := range messages[locale] {
+			if err := trans.Add(k, v, true); err != nil {
+				return errors.Wrapf(err, "add message %s for %s", k, locale)
+			}
+		}
+	}
+	return nil
+}
+
+// message 取指定语言的提示，翻译器未初始化时使用默认语言的原文
+func message(locale Locale, key string, params ...string) string {
+	if trans := getTranslator(locale); trans != nil {
+		if msg, err := trans.T(key, params...); err == nil {
+			return msg
+		}
+	}
+
+	msg := messages[DefaultLocale()][key]
+	for i, v := range params {
+		msg = strings.Replace(msg, "{"+strconv.Itoa(i)+"}", v, 1)
+	}
+	return msg
+}
+
+// bindErrorCusError 将解码阶段的错误转换为翻译后的 CusError，不能识别的错误返回 nil
+// 支持严格模式的违规、json 的类型不匹配及语法错误、form 绑定中的数字格式错误及请求体超过 http.MaxBytesReader 的限制
+// msgpack、protobuf、yaml、toml、xml 请求体的解码错误统一提示格式错误
+func bindErrorCusError(c *gin.Context, err error, obj any, b namedBinding) *CusError {
+	locale := GetLocale(c)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		msg := message(locale, msgBodyTooLarge, strconv.FormatInt(maxBytesErr.Limit, 10))
+		return newCusError(ErrCodePayloadTooLarge, err, msg, nil)
+	}
+
+	// 其它格式的解码器不报告字段，统一提示请求体格式错误
+	if name, ok := bodyFormatName(b); ok {
+		if _, isProto := obj.(proto.Message); name == "Protobuf" && !isProto {
+			return nil
+		}
+		return newCusError(ErrCodeBadReq, err, message(locale, msgBodyInvalid, name), nil)
+	}
+
+	var strictErr *StrictJSONError
+	if errors.As(err, &strictErr) {
+		return fieldsCusError(err, strictFieldErrors(locale, strictErr))
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return newCusError(ErrCodeBadReq, err, message(locale, msgJsonSyntax, strconv.FormatInt(syntaxErr.Offset, 10)), nil)
+	}
+	// form、multipart 等请求体读取中断时也会返回 io.ErrUnexpectedEOF，只有 json 提示 JSON 不完整
+	if errors.Is(err, io.ErrUnexpectedEOF) && b != nil && b.Name() == "json" {
+		return newCusError(ErrCodeBadReq, err, message(locale, msgJsonEOF), nil)
+	}
+
+	// 请求体为空或只有空白
+	if errors.Is(err, io.EOF) && b != nil && b.Name() == "json" {
+		return newCusError(ErrCodeBadReq, err, message(locale, msgBodyEmpty), nil)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	// 顶层类型不匹配，如对象传了数组，没有对应的字段
+	if errors.As(err, &typeErr) && typeErr.Field == "" {
+		return newCusError(ErrCodeBadReq, err, message(locale, msgBodyKind, message(locale, kindMessage(typeErr.Type))), nil)
+	}
+	if errors.As(err, &typeErr) {
+		fe := FieldError{
+			Tag:   bindErrTagType,
+			Param: kindKey(typeErr.Type),
+		}
+		fld := jsonStructField(reflect.TypeOf(obj), typeErr.Field, &fe)
+		fe.Message = message(locale, msgTypeMismatch, bindErrLabel(locale, fld, fe.JsonPath), message(locale, kindMessage(typeErr.Type)))
+		return fieldsCusError(err, []FieldError{fe})
+	}
+
+	var queryErr *QueryDecodeError
+	if errors.As(err, &queryErr) {
+		fe := FieldError{
+			Field:    queryErr.Field,
+			JsonPath: queryErr.Key,
+			Tag:      bindErrTagType,
+		}
+		name := queryErr.Key[strings.LastIndex(queryErr.Key, "[")+1:]
+		label := strings.TrimSuffix(name, "]")
+		if l := fieldLabel(locale, queryErr.sf, queryErr.Key, label); l != "" {
+			label = l
+		}
+		if errors.Is(queryErr.Err, strconv.ErrRange) {
+			fe.Tag = bindErrTagRange
+			fe.Message = message(locale, msgOutOfRange, label)
+		} else {
+			kind, layouts := kindMessage(queryErr.Type), defaultTimeLayouts
+			switch queryErr.Type {
+			case timeType:
+				kind = msgKindTime
+				if f := queryErr.sf; f != nil && f.Tag.Get("time_format") != "" {
+					layouts = []string{f.Tag.Get("time_format")}
+				}
+			case durationType:
+				kind = msgKindDuration
+			}
+			fe.Param = strings.TrimPrefix(kind, "gi_kind_")
+			fe.Message = message(locale, msgTypeMismatch, label, message(locale, kind, strings.Join(layouts, " / ")))
+		}
+		return fieldsCusError(err, []FieldError{fe})
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		fe := FieldError{Tag: bindErrTagType}
+		fld, ok := formStructField(c, reflect.TypeOf(obj), b, numErr.Num, &fe)
+		if !ok {
+			return newCusError(ErrCodeBadReq, err, message(locale, msgInvalidValue, numErr.Num), nil)
+		}
+		if errors.Is(numErr.Err, strconv.ErrRange) {
+			fe.Tag = bindErrTagRange
+			fe.Message = message(locale, msgOutOfRange, bindErrLabel(locale, fld, fe.JsonPath))
+		} else {
+			fe.Param = kindKey(scalarType(fld.Type))
+			fe.Message = message(locale, msgTypeMismatch, bindErrLabel(locale, fld, fe.JsonPath), message(locale, kindMessage(scalarType(fld.Type))))
+		}
+		return fieldsCusError(err, []FieldError{fe})
+	}
+
+	return nil
+}
+
+// strictFieldErrors 严格模式的违规，未知字段没有对应的 Go 字段，Field 为空
+func strictFieldErrors(locale Locale, err *StrictJSONError) []FieldError {
+	l := make([]FieldError, 0, len(err.Violations))
+	for _, v := range err.Violations {
+		fe := FieldError{JsonPath: v.Path, Tag: v.Kind}
+		switch v.Kind {
+		case StrictUnknownField:
+			fe.Message = message(locale, msgUnknownField, v.Path)
+		case StrictDuplicateKey:
+			fe.Message = message(locale, msgDuplicateKey, v.Path)
+		case StrictMaxDepth:
+			fe.Param = strconv.Itoa(err.MaxDepth)
+			fe.Message = message(locale, msgMaxDepth, fe.Param)
+		default:
+			fe.Message = message(locale, msgTrailingData)
+		}
+		l = append(l, fe)
+	}
+	return l
+}
+
+// bodyFormatName json 以外的请求体格式在提示中的名称
+func bodyFormatName(b namedBinding) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	switch b.Name() {
+	case "msgpack":
+		return "MessagePack", true
+	case "protobuf":
+		return "Protobuf", true
+	case "yaml":
+		return "YAML", true
+	case "toml":
+		return "TOML", true
+	case "xml":
+		return "XML", true
+	}
+	return "", false
+}
+
+// bindErrLabel 字段在提示中的显示名，没有 label 时为客户端字段名
+func bindErrLabel(locale Locale, fld *reflect.StructField, path string) string {
+	name, _, _ := strings.Cut(path[strings.LastIndex(path, ".")+1:], "[")
+	if label := fieldLabel(locale, fld, path, name); label != "" {
+		return label
+	}
+	return name
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// kindKey 期望的类型，作为 FieldError 的 Param
+func kindKey(t reflect.Type) string {
+	return strings.TrimPrefix(kindMessage(t), "gi_kind_")
+}
+
+// kindMessage 期望类型的提示 key
+func kindMessage(t reflect.Type) string {
+	t = indirectType(t)
+	if t == nil {
+		return msgKindObject
+	}
+	if t == reflect.TypeFor[time.Time]() || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return msgKindString
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return msgKindInteger
+	case reflect.Float32, reflect.Float64:
+		return msgKindNumber
+	case reflect.String:
+		return msgKindString
+	case reflect.Bool:
+		return msgKindBool
+	case reflect.Slice, reflect.Array:
+		return msgKindArray
+	}
+	return msgKindObject
+}
+
+// jsonStructField 按 encoding/json 报告的路径（json 字段名以 . 分隔，新版本中含数字下标）查找字段
+// 设置 fe.JsonPath 为与校验错误一致的客户端路径，如 items[0].qty，找到字段时设置 fe.Field 为 Go 字段路径
+func jsonStructField(t reflect.Type, path string, fe *FieldError) *reflect.StructField {
+	var (
+		jsonPath, goPath []string
+		fld              *reflect.StructField
+	)
+	for _, seg := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(seg); err == nil && len(jsonPath) > 0 {
+			jsonPath[len(jsonPath)-1] += "[" + seg + "]"
+			if fld != nil {
+				goPath[len(goPath)-1] += "[" + seg + "]"
+			}
+			continue
+		}
+		jsonPath = append(jsonPath, seg)
+
+		if t = indirectStructType(t); t == nil {
+			fld = nil
+			continue
+		}
+		f, names, ok := findField(t, func(f reflect.StructField) bool {
+			name := tagName(f, "json")
+			return name == seg || name == "" && strings.EqualFold(f.Name, seg)
+		})
+		if !ok {
+			fld, t = nil, nil
+			continue
+		}
+		goPath = append(goPath, names...)
+		fld, t = &f, f.Type
+	}
+
+	fe.JsonPath = strings.Join(jsonPath, ".")
+	if fld != nil {
+		fe.Field = strings.Join(goPath, ".")
+	}
+	return fld
+}
+
+// formStructField 按 form、uri、header 绑定中出错的值查找字段，找到时设置 fe.Field 及 fe.JsonPath
+// strconv.NumError 不含字段名，只能通过请求中的值反查；只考虑数字及布尔类型的字段，多个字段都有该值时无法确定，返回 false
+func formStructField(c *gin.Context, t reflect.Type, b namedBinding, value string, fe *FieldError) (*reflect.StructField, bool) {
+	key := "form"
+	if b != nil && bindingTagKey(b) != "" {
+		key = bindingTagKey(b)
+	}
+
+	lookup := func(name string) []string {
+		switch key {
+		case "uri":
+			v, ok := c.Params.Get(name)
+			if !ok {
+				return nil
+			}
+			return []string{v}
+		case "header":
+			return c.Request.Header.Values(name)
+		}
+		if c.Request.Form != nil {
+			return c.Request.Form[name]
+		}
+		return c.Request.URL.Query()[name]
+	}
+
+	var (
+		found []reflect.StructField
+		match FieldError
+	)
+	walkFormFields(indirectStructType(t), key, nil, func(f reflect.StructField, goPath []string) bool {
+		switch kindMessage(scalarType(f.Type)) {
+		case msgKindInteger, msgKindNumber, msgKindBool:
+		default:
+			return false
+		}
+		name := tagName(f, key)
+		if name == "" {
+			name = f.Name
+		}
+		if slices.Contains(lookup(name), value) {
+			found = append(found, f)
+			match = FieldError{Field: strings.Join(goPath, "."), JsonPath: name}
+		}
+		return false
+	})
+	if len(found) != 1 {
+		return nil, false
+	}
+	fe.Field, fe.JsonPath = match.Field, match.JsonPath
+	return &found[0], true
+}
+
+// scalarType slice、array 的元素类型，form 中的多个值逐个解码
+func scalarType(t reflect.Type) reflect.Type {
+	if et := elemType(t); et != nil && indirectType(t).Kind() != reflect.Map {
+		return et
+	}
+	return t
+}
+
+// walkFormFields 与 gin 的 form 绑定一致，递归进入嵌套的结构体，字段名不加前缀
+func walkFormFields(t reflect.Type, key string, goPath []string, match func(reflect.StructField, []string) bool) (*reflect.StructField, bool) {
+	if t == nil {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get(key) == "-" {
+			continue
+		}
+		p := append(slices.Clone(goPath), f.Name)
+
+		if st := indirectStructType(f.Type); st != nil && kindMessage(f.Type) == msgKindObject {
+			if found, ok := walkFormFields(st, key, p, match); ok {
+				return found, true
+			}
+			continue
+		}
+		if match(f, p) {
+			return &f, true
+		}
+	}
+	return nil, false
+}
+
+// findField 在 t 及其嵌入的结构体中查找字段，返回字段及其 Go 字段路径（含嵌入的结构体名）
+func findField(t reflect.Type, match func(reflect.StructField) bool) (reflect.StructField, []string, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous && tagName(f, "json") == "" && indirectStructType(f.Type) != nil {
+			continue
+		}
+		if !f.IsExported() || !match(f) {
+			continue
+		}
+
+		var names []string
+		ft := t
+		for _, i := range f.Index {
+			sf := indirectType(ft).Field(i)
+			names = append(names, sf.Name)
+			ft = sf.Type
+		}
+		return f, names, true
+	}
+	return reflect.StructField{}, nil, false
+}
+
+// indirectStructType 去掉指针及 slice、array、map，取结构体类型，不是结构体时返回 nil
+func indirectStructType(t reflect.Type) reflect.Type {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package gi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindErrReq struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// bindBody 以 h.Binding 绑定 json 请求体，返回状态码及纯文本的错误提示
+func bindBody(t *testing.T, body string, mid ...gin.HandlerFunc) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := New()
+	r.Use(MidLocale())
+	r.Use(mid...)
+	r.POST("/", func(c *gin.Context) {
+		var req bindErrReq
+		if (&BaseHdl{}).Binding(c, &req) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestBindingJsonBodyErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", "request body must not be empty"},
+		{"whitespace", " \n\t", "request body must not be empty"},
+		{"array", "[1]", "request body must be an object"},
+		{"string", `"x"`, "request body must be an object"},
+		{"incomplete", `{"name":`, "request body JSON is incomplete"},
+		{"syntax", `{"name":}`, "request body is not valid JSON (offset 9)"},
+		{"field type", `{"age":"x"}`, "age must be an integer"},
+	}
+	for _, tc := range cases {
+		for _, strict := range []bool{false, true} {
+			var mid []gin.HandlerFunc
+			if strict {
+				mid = append(mid, MidStrictJSON())
+			}
+			code, msg := bindBody(t, tc.body, mid...)
+			if code != http.StatusBadRequest || msg != tc.want {
+				t.Errorf("%s (strict %v): %d %q, want 400 %q", tc.name, strict, code, msg, tc.want)
+			}
+			if strings.Contains(msg, "gi.") {
+				t.Errorf("%s (strict %v): message leaks Go type: %q", tc.name, strict, msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package gi
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Query 支持数组、方括号 map 及嵌套对象的 query binding，字段名取 form tag，兼容 binding.Query 的写法
+//   - 重复的 key 及方括号：ids=1&ids=2、ids[]=1&ids[]=2、ids[0]=1&ids[1]=2
+//   - 分隔符：字段带 collection_format tag 时拆分每个值，可选 csv（逗号）、ssv（空格）、tsv（制表符）、pipes（竖线）、multi（不拆分，默认）
+//   - map 及嵌套对象：filter[status]=paid&filter[created_at][gte]=2024-01-01，对应 map 或带 form tag 的结构体字段
+//   - 没有 form tag 的结构体字段与 gin 一致，其字段直接从当前层级取值，不加前缀
+//   - form tag 中的 default=xxx 选项与 gin 一致，参数不存在时使用，slice 未指定 collection_format 时按逗号拆分；也可使用 default tag，见 applyDefaults
+//   - 单个值的解析与 gin 一致：空值为零值，支持 binding.BindUnmarshaler、time_format（含 unix、unixmilli 等）、time_utc 及 time_location
+//
+// BaseHdl.Binding 默认仍使用 gin 的 binding，需要时显式传入，如 h.Binding(c, &req, gi.Query)；Bind 中的 query 使用 Query
+var Query binding.Binding = richQueryBinding{}
+
+type richQueryBinding struct{}
+
+func (richQueryBinding) Name() string {
+	return "query"
+}
+
+func (richQueryBinding) Bind(req *http.Request, obj any) error {
+	if err := DecodeQuery(req.URL.Query(), obj); err != nil {
+		return err
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// QueryDecodeError query 参数无法转换为字段类型
+type QueryDecodeError struct {
+	Key   string       // 请求中的 key，如 filter[created_at][gte]
+	Field string       // Go 字段路径，如 Filter.CreatedAt.Gte
+	Type  reflect.Type // 字段类型
+	Value string
+	Err   error
+
+	sf *reflect.StructField
+}
+
+func (p *QueryDecodeError) Error() string {
+	return fmt.Sprintf("query %s: cannot decode %q into %s: %v", p.Key, p.Value, p.Type, p.Err)
+}
+
+func (p *QueryDecodeError) Unwrap() error {
+	return p.Err
+}
+
+// DecodeQuery 按 Query 的规则将 values 解码到 obj，obj 须为结构体指针，不做校验
+func DecodeQuery(values url.Values, obj any) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.Newf("gi: DecodeQuery requires a non-nil pointer to struct, got %T", obj)
+	}
+
+	root := &queryNode{}
+	for k, vs := range values {
+		root.add(parseQueryKey(k), vs)
+	}
+	_, err := decodeQueryStruct(v.Elem(), root, "", nil)
+	return err
+}
+
+// queryNode query key 按方括号拆分后的树，ids[]=1 的值记在 ids 上
+type queryNode struct {
+	values   []string
+	children map[string]*queryNode
+}
+
+func (p *queryNode) add(path []string, vs []string) {
+	n := p
+	for _, seg := range path {
+		if n.children == nil {
+			n.children = map[string]*queryNode{}
+		}
+		child, ok := n.children[seg]
+		if !ok {
+			child = &queryNode{}
+			n.children[seg] = child
+		}
+		n = child
+	}
+	n.values = append(n.values, vs...)
+}
+
+func (p *queryNode) empty() bool {
+	return len(p.values) == 0 && len(p.children) == 0
+}
+
+// indexed 子节点都是数字下标（如 items[0][sku]）时按下标排序返回
+func (p *queryNode) indexed() ([]*queryNode, bool) {
+	if len(p.children) == 0 {
+		return nil, false
+	}
+	idx := make([]int, 0, len(p.children))
+	for k := range p.children {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 {
+			return nil, false
+		}
+		idx = append(idx, i)
+	}
+	slices.Sort(idx)
+
+	l := make([]*queryNode, 0, len(idx))
+	for _, i := range idx {
+		l = append(l, p.children[strconv.Itoa(i)])
+	}
+	return l, true
+}
+
+// parseQueryKey a[b][c] 拆分为 [a b c]，ids[] 拆分为 [ids]，方括号不完整时整体作为 key
+func parseQueryKey(k string) []string {
+	i := strings.IndexByte(k, '[')
+	if i <= 0 || !strings.HasSuffix(k, "]") {
+		return []string{k}
+	}
+
+	path := []string{k[:i]}
+	for _, seg := range strings.Split(k[i+1:len(k)-1], "][") {
+		if strings.ContainsAny(seg, "[]") {
+			return []string{k}
+		}
+		path = append(path, seg)
+	}
+	if path[len(path)-1] == "" {
+		path = path[:len(path)-1]
+	}
+	return path
+}
+
+func queryChildKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "[" + name + "]"
+}
+
+// decodeQueryStruct 返回是否设置了任一字段
+func decodeQueryStruct(v reflect.Value, n *queryNode, key string, goPath []string) (bool, error) {
+	t := v.Type()
+	set := false
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		fv := v.Field(i)
+
+		tag, opts, _ := strings.Cut(fld.Tag.Get("form"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		// 没有 form tag 的结构体字段（含嵌入的结构体）直接从当前层级取值
+		if tag == "" && indirectType(fld.Type).Kind() == reflect.Struct && !isQueryScalar(indirectType(fld.Type)) {
+			if !fld.IsExported() && !fld.Anonymous {
+				continue
+			}
+			ok, err := decodeQueryFlatten(fv, n, key, append(slices.Clone(goPath), fld.Name))
+			if err != nil {
+				return set, err
+			}
+			set = set || ok
+			continue
+		}
+		if !fv.CanSet() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = fld.Name
+		}
+		child := n.children[name]
+		if child == nil || child.empty() {
+			def, ok := strings.CutPrefix(opts, "default=")
+			if !ok {
+				continue
+			}
+			child = &queryNode{values: []string{def}}
+			if indirectType(fld.Type).Kind() == reflect.Slice && collectionSep(&fld) == "" {
+				child.values = strings.Split(def, ",")
+			}
+		}
+
+		ok, err := decodeQueryValue(fv, child, queryChildKey(key, name), append(slices.Clone(goPath), fld.Name), &fld)
+		if err != nil {
+			return set, err
+		}
+		set = set || ok
+	}
+	return set, nil
+}
+
+// decodeQueryFlatten 结构体指针为 nil 时，只在有字段被设置时才分配
+func decodeQueryFlatten(v reflect.Value, n *queryNode, key string, goPath []string) (bool, error) {
+	if v.Kind() != reflect.Ptr {
+		return decodeQueryStruct(v, n, key, goPath)
+	}
+	if !v.IsNil() {
+		return decodeQueryStruct(v.Elem(), n, key, goPath)
+	}
+	if !v.CanSet() {
+		return false, nil
+	}
+
+	p := reflect.New(v.Type().Elem())
+	ok, err := decodeQueryStruct(p.Elem(), n, key, goPath)
+	if ok {
+		v.Set(p)
+	}
+	return ok, err
+}
+
+func decodeQueryValue(v reflect.Value, n *queryNode, key string, goPath []string, sf *reflect.StructField) (bool, error) {
+	if n.empty() {
+		return false, nil
+	}
+
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.Ptr:
+		p := reflect.New(t.Elem())
+		if !v.IsNil() {
+			p = v
+		}
+		ok, err := decodeQueryValue(p.Elem(), n, key, goPath, sf)
+		if ok {
+			v.Set(p)
+		}
+		return ok, err
+	case isQueryScalar(t):
+		if len(n.values) == 0 {
+			return false, nil
+		}
+		return true, setQueryScalar(v, n.values[0], key, goPath, sf)
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return decodeQuerySlice(v, n, key, goPath, sf)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return false, nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for k, child := range n.children {
+			elem := reflect.New(t.Elem()).Elem()
+			ok, err := decodeQueryValue(elem, child, queryChildKey(key, k), indexPath(goPath, k), sf)
+			if err != nil {
+				return true, err
+			}
+			if ok {
+				v.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+			}
+		}
+		return true, nil
+	case reflect.Struct:
+		return decodeQueryStruct(v, n, key, goPath)
+	}
+	return false, nil
+}
+
+func decodeQuerySlice(v reflect.Value, n *queryNode, key string, goPath []string, sf *reflect.StructField) (bool, error) {
+	t := v.Type()
+
+	// items[0][sku]=a 形式
+	if children, ok := n.indexed(); ok && len(n.values) == 0 {
+		l := reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(children), len(children))
+		for i, child := range children {
+			if _, err := decodeQueryValue(l.Index(i), child, queryChildKey(key, strconv.Itoa(i)), indexPath(goPath, strconv.Itoa(i)), sf); err != nil {
+				return true, err
+			}
+		}
+		setQuerySlice(v, l)
+		return true, nil
+	}
+
+	var vals []string
+	sep := collectionSep(sf)
+	for _, s := range n.values {
+		if sep == "" {
+			vals = append(vals, s)
+			continue
+		}
+		for _, part := range strings.Split(s, sep) {
+			if part = strings.TrimSpace(part); part != "" {
+				vals = append(vals, part)
+			}
+		}
+	}
+	if len(vals) == 0 {
+		return false, nil
+	}
+
+	l := reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(vals), len(vals))
+	for i, s := range vals {
+		if _, err := decodeQueryValue(l.Index(i), &queryNode{values: []string{s}}, key, indexPath(goPath, strconv.Itoa(i)), sf); err != nil {
+			return true, err
+		}
+	}
+	setQuerySlice(v, l)
+	return true, nil
+}
+
+// setQuerySlice array 只取前 len 个
+func setQuerySlice(v reflect.Value, l reflect.Value) {
+	if v.Kind() == reflect.Slice {
+		v.Set(l)
+		return
+	}
+	for i := 0; i < v.Len() && i < l.Len(); i++ {
+		v.Index(i).Set(l.Index(i))
+	}
+}
+
+// indexPath Go 字段路径的最后一段加上下标或 map key，如 Items[0]
+func indexPath(goPath []string, idx string) []string {
+	p := slices.Clone(goPath)
+	p[len(p)-1] += "[" + idx + "]"
+	return p
+}
+
+// collectionSep collection_format tag 对应的分隔符
+func collectionSep(sf *reflect.StructField) string {
+	if sf == nil {
+		return ""
+	}
+	switch sf.Tag.Get("collection_format") {
+	case "csv":
+		return ","
+	case "ssv":
+		return " "
+	case "tsv":
+		return "\t"
+	case "pipes":
+		return "|"
+	}
+	return ""
+}
+
+var bindUnmarshalerType = reflect.TypeFor[binding.BindUnmarshaler]()
+
+// isQueryScalar 以单个字符串表示的类型
+func isQueryScalar(t reflect.Type) bool {
+	if t == timeType || reflect.PointerTo(t).Implements(bindUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func setQueryScalar(v reflect.Value, s, key string, goPath []string, sf *reflect.StructField) error {
+	var err error
+	switch {
+	case reflect.PointerTo(v.Type()).Implements(bindUnmarshalerType):
+		err = v.Addr().Interface().(binding.BindUnmarshaler).UnmarshalParam(s)
+	case v.Type() == timeType:
+		var t time.Time
+		if t, err = parseQueryTime(s, sf); err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshalerType):
+		err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	case s == "" && v.Kind() != reflect.String:
+		// 与 gin 一致，数字、布尔的空值为零值
+		v.SetZero()
+	default:
+		err = setDefault(v, s, reflect.StructField{})
+	}
+	if err == nil {
+		return nil
+	}
+	return &QueryDecodeError{
+		Key:   key,
+		Field: strings.Join(goPath, "."),
+		Type:  v.Type(),
+		Value: s,
+		Err:   err,
+		sf:    sf,
+	}
+}
+
+// parseQueryTime 与 gin 一致：time_format 可为 unix、unixmilli、unixmicro、unixnano，空值为零值，
+// time_utc 为 true 时按 UTC、time_location 指定时区解析；未指定 time_format 时依次尝试 defaultTimeLayouts
+func parseQueryTime(s string, sf *reflect.StructField) (time.Time, error) {
+	var tag reflect.StructTag
+	if sf != nil {
+		tag = sf.Tag
+	}
+
+	format := tag.Get("time_format")
+	switch tf := strings.ToLower(format); tf {
+	case "unix", "unixmilli", "unixmicro", "unixnano":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch tf {
+		case "unix":
+			return time.Unix(n, 0), nil
+		case "unixmilli":
+			return time.UnixMilli(n), nil
+		case "unixmicro":
+			return time.UnixMicro(n), nil
+		}
+		return time.Unix(0, n), nil
+	}
+
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	loc := time.Local
+	if utc, _ := strconv.ParseBool(tag.Get("time_utc")); utc {
+		loc = time.UTC
+	}
+	if name := tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+
+	layouts := defaultTimeLayouts
+	if format != "" {
+		layouts = []string{format}
+	}
+	return parseTime(s, layouts, loc)
+}
@@ -0,0 +1,104 @@
+package gi
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+type queryRange struct {
+	Gte string `form:"gte"`
+	Lte string `form:"lte"`
+}
+
+type queryPaging struct {
+	Page int `form:"page,default=1"`
+}
+
+type queryReq struct {
+	Ids     []int                        `form:"ids"`
+	Tags    []string                     `form:"tags"`
+	Csv     []int                        `form:"csv" collection_format:"csv"`
+	Filter  map[string]string            `form:"filter"`
+	Nested  map[string]map[string]string `form:"nested"`
+	Created queryRange                   `form:"created"`
+	PRange  *queryRange                  `form:"p_range"`
+	Items   []queryRange                 `form:"items"`
+	On      bool                         `form:"on"`
+	At      time.Time                    `form:"at" time_format:"unix"`
+	queryPaging
+}
+
+func TestDecodeQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		want  queryReq
+	}{
+		{"", queryReq{queryPaging: queryPaging{Page: 1}}},
+		{"ids=1&ids=2", queryReq{Ids: []int{1, 2}, queryPaging: queryPaging{1}}},
+		{"ids[]=1&ids[]=2", queryReq{Ids: []int{1, 2}, queryPaging: queryPaging{1}}},
+		{"ids[1]=2&ids[0]=1", queryReq{Ids: []int{1, 2}, queryPaging: queryPaging{1}}},
+		{"csv=1,2,3", queryReq{Csv: []int{1, 2, 3}, queryPaging: queryPaging{1}}},
+		// a[]= 为一个空字符串，与 gin 的 tags= 一致
+		{"tags[]=", queryReq{Tags: []string{""}, queryPaging: queryPaging{1}}},
+		{"tags=", queryReq{Tags: []string{""}, queryPaging: queryPaging{1}}},
+		{"tags[]=&tags[]=a", queryReq{Tags: []string{"", "a"}, queryPaging: queryPaging{1}}},
+		{"filter[status]=paid&filter[x]=", queryReq{Filter: map[string]string{"status": "paid", "x": ""}, queryPaging: queryPaging{1}}},
+		// a[b][c]=
+		{"nested[a][b]=c&nested[a][d]=", queryReq{Nested: map[string]map[string]string{"a": {"b": "c", "d": ""}}, queryPaging: queryPaging{1}}},
+		{"created[gte]=1&created[lte]=", queryReq{Created: queryRange{Gte: "1"}, queryPaging: queryPaging{1}}},
+		{"p_range[gte]=x", queryReq{PRange: &queryRange{Gte: "x"}, queryPaging: queryPaging{1}}},
+		{"p_range[gte]=", queryReq{PRange: &queryRange{}, queryPaging: queryPaging{1}}},
+		{"items[0][gte]=a&items[1][lte]=b", queryReq{Items: []queryRange{{Gte: "a"}, {Lte: "b"}}, queryPaging: queryPaging{1}}},
+		{"on=&page=3", queryReq{queryPaging: queryPaging{3}}},
+		{"on=true", queryReq{On: true, queryPaging: queryPaging{1}}},
+		{"at=1700000000", queryReq{At: time.Unix(1700000000, 0), queryPaging: queryPaging{1}}},
+		// 无法对应字段的 key 忽略
+		{"ids[a]=1&filter[a][b]=c", queryReq{Filter: map[string]string{}, queryPaging: queryPaging{1}}},
+	}
+	for _, tc := range cases {
+		values, _ := url.ParseQuery(tc.query)
+		var got queryReq
+		if err := DecodeQuery(values, &got); err != nil {
+			t.Errorf("%q: %v", tc.query, err)
+			continue
+		}
+		if got.At.Equal(tc.want.At) {
+			got.At = tc.want.At
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q:\n got %+v\nwant %+v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeQueryError(t *testing.T) {
+	cases := []struct {
+		query string
+		key   string
+		field string
+	}{
+		{"ids=x", "ids", "Ids[0]"},
+		{"ids[0]=1&ids[1]=x", "ids[1]", "Ids[1]"},
+		{"csv=1,x", "csv", "Csv[1]"},
+		{"on=maybe", "on", "On"},
+		{"page=x", "page", "queryPaging.Page"},
+		{"at=x", "at", "At"},
+	}
+	for _, tc := range cases {
+		values, _ := url.ParseQuery(tc.query)
+		var got queryReq
+		err := DecodeQuery(values, &got)
+		var qe *QueryDecodeError
+		if !errors.As(err, &qe) {
+			t.Errorf("%q: err = %v, want QueryDecodeError", tc.query, err)
+			continue
+		}
+		if qe.Key != tc.key || qe.Field != tc.field {
+			t.Errorf("%q: key %q field %q, want %q %q", tc.query, qe.Key, qe.Field, tc.key, tc.field)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package gi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const strictJSONKey = "gi.strictJSON"
+
+// defaultStrictMaxDepth 严格模式默认的最大嵌套层级
+const defaultStrictMaxDepth = 32
+
+// 严格模式的违规类型，同时作为 FieldError 的 Tag
+const (
+	StrictUnknownField = "unknown_field" // 目标结构体中没有的字段
+	StrictDuplicateKey = "duplicate_key" // 同一对象中重复的 key
+	StrictMaxDepth     = "max_depth"     // 嵌套层级超出限制
+	StrictTrailingData = "trailing_data" // JSON 之后有多余的内容
+)
+
+// StrictJSONViolation 一处违规，Path 为客户端字段路径，如 items[0].sku_id
+type StrictJSONViolation struct {
+	Path string
+	Kind string
+}
+
+// StrictJSONError 严格模式的解码错误，包含全部未知字段及重复的 key；层级超限或有多余内容时不再继续检查
+type StrictJSONError struct {
+	Violations []StrictJSONViolation
+	MaxDepth   int
+}
+
+func (p *StrictJSONError) Error() string {
+	l := make([]string, 0, len(p.Violations))
+	for _, v := range p.Violations {
+		l = append(l, v.Kind+" "+v.Path)
+	}
+	return "strict json: " + strings.Join(l, ", ")
+}
+
+// StrictOpt 严格模式的配置项
+type StrictOpt func(*strictJSONBinding)
+
+// StrictWithMaxDepth 最大嵌套层级，默认为 32
+func StrictWithMaxDepth(n int) StrictOpt {
+	return func(p *strictJSONBinding) {
+		p.maxDepth = n
+	}
+}
+
+// StrictJSON 严格的 json binding：不允许未知字段、重复的 key 及 JSON 之后的多余内容，并限制嵌套层级
+// 字段名区分大小写；json.RawMessage、interface{} 及实现了 json.Unmarshaler 的字段不检查未知字段
+// 可直接传给 BaseHdl.Binding，如 h.Binding(c, &req, gi.StrictJSON)
+var StrictJSON binding.BindingBody = newStrictJSON(nil)
+
+func newStrictJSON(opt []StrictOpt) strictJSONBinding {
+	p := strictJSONBinding{maxDepth: defaultStrictMaxDepth}
+	for _, v := range opt {
+		v(&p)
+	}
+	return p
+}
+
+type strictJSONBinding struct {
+	maxDepth int
+}
+
+// Name 与 binding.JSON 一致，字段路径取 json tag
+func (strictJSONBinding) Name() string {
+	return "json"
+}
+
+func (p strictJSONBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return p.BindBody(body, obj)
+}
+
+func (p strictJSONBinding) BindBody(body []byte, obj any) error {
+	if err := p.check(body, reflect.TypeOf(obj)); err != nil {
+		return err
+	}
+	return binding.JSON.BindBody(body, obj)
+}
+
+// check 逐个 token 扫描，对照 t 检查未知字段，同时检查重复的 key、嵌套层级及多余内容
+// 语法错误原样返回，由 json 解码时报告的错误处理
+func (p strictJSONBinding) check(body []byte, t reflect.Type) error {
+	s := &strictScanner{
+		dec:      json.NewDecoder(bytes.NewReader(body)),
+		maxDepth: p.maxDepth,
+	}
+	switch err := s.value(t, "", 0); {
+	case errors.Is(err, errStrictStop):
+	case err != nil:
+		// 语法错误由随后的 json 解码报告
+		return nil
+	default:
+		if _, err := s.dec.Token(); err != io.EOF {
+			s.add("", StrictTrailingData)
+		}
+	}
+
+	if len(s.violations) > 0 {
+		return &StrictJSONError{Violations: s.violations, MaxDepth: p.maxDepth}
+	}
+	return nil
+}
+
+var errStrictStop = errors.New("strict json stop")
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+type strictScanner struct {
+	dec        *json.Decoder
+	maxDepth   int
+	violations []StrictJSONViolation
+}
+
+func (p *strictScanner) add(path, kind string) {
+	p.violations = append(p.violations, StrictJSONViolation{Path: path, Kind: kind})
+}
+
+// value 扫描一个值，t 为 nil 时不检查未知字段
+func (p *strictScanner) value(t reflect.Type, path string, depth int) error {
+	tok, err := p.dec.Token()
+	if err != nil {
+		return err
+	}
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	if depth >= p.maxDepth {
+		p.add(path, StrictMaxDepth)
+		return errStrictStop
+	}
+
+	t = indirectType(t)
+	if t != nil && (t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(jsonUnmarshalerType)) {
+		t = nil
+	}
+
+	if d == '[' {
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; p.dec.More(); i++ {
+			if err := p.value(elem, path+"["+strconv.Itoa(i)+"]", depth+1); err != nil {
+				return err
+			}
+		}
+		_, err := p.dec.Token()
+		return err
+	}
+
+	seen := map[string]bool{}
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		if seen[key] {
+			p.add(childPath, StrictDuplicateKey)
+		}
+		seen[key] = true
+
+		var child reflect.Type
+		if t != nil {
+			switch t.Kind() {
+			case reflect.Map:
+				child = t.Elem()
+			case reflect.Struct:
+				f, ok := jsonField(t, key)
+				if !ok {
+					p.add(childPath, StrictUnknownField)
+				}
+				child = f.Type
+			}
+		}
+
+		if err := p.value(child, childPath, depth+1); err != nil {
+			return err
+		}
+	}
+	_, err = p.dec.Token()
+	return err
+}
+
+// jsonField 按 json 名称查找字段，嵌入的结构体会被展开
+// 与 encoding/json 不同，严格模式区分大小写，USER_NAME 不能匹配 user_name
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && f.Tag.Get("json") == "" && indirectStructType(f.Type) != nil {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// MidStrictJSON 对后续路由启用严格的 json 解码，BaseHdl.Binding 使用 binding.JSON 时改用 StrictJSON 的规则
+// 可用于路由组，旧接口所在的组可用 MidLaxJSON 关闭
+func MidStrictJSON(opt ...StrictOpt) gin.HandlerFunc {
+	b := newStrictJSON(opt)
+	return func(c *gin.Context) {
+		c.Set(strictJSONKey, b)
+	}
+}
+
+// MidLaxJSON 关闭 MidStrictJSON 或 WithStrictJSON 启用的严格模式
+func MidLaxJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(strictJSONKey, nil)
+	}
+}
+
+// WithStrictJSON 全局启用严格的 json 解码，见 MidStrictJSON，须在注册路由之前使用
+func WithStrictJSON(opt ...StrictOpt) GinOption {
+	return with(MidStrictJSON(opt...))
+}
+
+// strictBinding 当前请求启用了严格模式且 b 为 binding.JSON 时换成严格的 binding
+func strictBinding(c *gin.Context, b binding.Binding) binding.Binding {
+	if b != binding.JSON {
+		return b
+	}
+	if v, ok := c.Get(strictJSONKey); ok && v != nil {
+		return v.(binding.Binding)
+	}
+	return b
+}
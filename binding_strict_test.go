@@ -0,0 +1,115 @@
+package gi
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+)
+
+type strictItem struct {
+	SkuId int `json:"sku_id"`
+}
+
+type strictReq struct {
+	UserName string          `json:"user_name"`
+	Items    []strictItem    `json:"items"`
+	Extra    json.RawMessage `json:"extra"`
+	Any      any             `json:"any"`
+	strictEmbedded
+}
+
+type strictEmbedded struct {
+	Remark string `json:"remark"`
+}
+
+func TestStrictJSON(t *testing.T) {
+	New()
+
+	cases := []struct {
+		name string
+		body string
+		opt  []StrictOpt
+		want []StrictJSONViolation // 为空时应成功
+	}{
+		{name: "valid", body: `{"user_name":"a","items":[{"sku_id":1}],"remark":"r"}`},
+		{name: "raw message and any not checked", body: `{"extra":{"x":1},"any":{"y":[1]}}`},
+		{
+			name: "unknown fields",
+			body: `{"user_name":"a","foo":1,"items":[{"sku_id":1,"bar":2}]}`,
+			want: []StrictJSONViolation{{"foo", StrictUnknownField}, {"items[0].bar", StrictUnknownField}},
+		},
+		{
+			name: "case sensitive",
+			body: `{"User_Name":"a","USER_NAME":"b"}`,
+			want: []StrictJSONViolation{{"User_Name", StrictUnknownField}, {"USER_NAME", StrictUnknownField}},
+		},
+		{
+			name: "duplicate keys",
+			body: `{"user_name":"a","user_name":"b","items":[{"sku_id":1,"sku_id":2}]}`,
+			want: []StrictJSONViolation{{"user_name", StrictDuplicateKey}, {"items[0].sku_id", StrictDuplicateKey}},
+		},
+		{
+			name: "trailing data",
+			body: `{"user_name":"a"} {"user_name":"b"}`,
+			want: []StrictJSONViolation{{"", StrictTrailingData}},
+		},
+		{
+			name: "trailing whitespace allowed",
+			body: "{\"user_name\":\"a\"}\n  ",
+		},
+		{
+			name: "max depth",
+			body: `{"any":{"a":{"b":{"c":1}}}}`,
+			opt:  []StrictOpt{StrictWithMaxDepth(3)},
+			want: []StrictJSONViolation{{"any.a.b", StrictMaxDepth}},
+		},
+		{
+			name: "within max depth",
+			body: `{"any":{"a":{"b":1}}}`,
+			opt:  []StrictOpt{StrictWithMaxDepth(3)},
+		},
+	}
+	for _, tc := range cases {
+		var req strictReq
+		err := newStrictJSON(tc.opt).BindBody([]byte(tc.body), &req)
+		if len(tc.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		var se *StrictJSONError
+		if !errors.As(err, &se) {
+			t.Errorf("%s: err = %v, want StrictJSONError", tc.name, err)
+			continue
+		}
+		if !slices.Equal(se.Violations, tc.want) {
+			t.Errorf("%s: violations = %+v, want %+v", tc.name, se.Violations, tc.want)
+		}
+	}
+}
+
+func TestStrictJSONMessages(t *testing.T) {
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`{"name":"a","foo":1}`, "foo is not a known field"},
+		{`{"name":"a","name":"b"}`, "name is duplicated"},
+		{`{"name":"a"}[]`, "request body has extra data after the JSON value"},
+	}
+	for _, tc := range cases {
+		code, msg := bindBody(t, tc.body, MidStrictJSON())
+		if code != http.StatusBadRequest || msg != tc.want {
+			t.Errorf("%s: %d %q, want 400 %q", tc.body, code, msg, tc.want)
+		}
+	}
+
+	// MidLaxJSON 关闭后忽略未知字段
+	if code, msg := bindBody(t, `{"name":"a","foo":1}`, MidStrictJSON(), MidLaxJSON()); code != http.StatusOK {
+		t.Errorf("lax: %d %q, want 200", code, msg)
+	}
+}
@@ -3,23 +3,36 @@ package gi
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
 	"github.com/gin-gonic/gin"
 	"github.com/quexer/utee"
 	log "github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 const (
-	ErrCodeOk           ErrCode = 0
-	ErrCodeNotModified  ErrCode = 304
-	ErrCodeBadReq       ErrCode = 400
-	ErrCodeUnauthorized ErrCode = 401
-	ErrCodeForbidden    ErrCode = 403
-	ErrCodeNotFound     ErrCode = 404
-	ErrCodeInternalErr  ErrCode = 500
-	ErrCodePanicErr     ErrCode = 590 // internal error, but panic error
+	ErrCodeOk                 ErrCode = 0
+	ErrCodeNotModified        ErrCode = 304
+	ErrCodeBadReq             ErrCode = 400
+	ErrCodeUnauthorized       ErrCode = 401
+	ErrCodeForbidden          ErrCode = 403
+	ErrCodeNotFound           ErrCode = 404
+	ErrCodeMethodNotAllowed   ErrCode = 405
+	ErrCodeConflict           ErrCode = 409
+	ErrCodeGone               ErrCode = 410
+	ErrCodePreconditionFailed ErrCode = 412
+	ErrCodePayloadTooLarge    ErrCode = 413
+	ErrCodeUnprocessable      ErrCode = 422
+	ErrCodeTooManyRequests    ErrCode = 429
+	ErrCodeClientClosed       ErrCode = 499 // client closed request, nginx 约定
+	ErrCodeInternalErr        ErrCode = 500
+	ErrCodeServiceUnavailable ErrCode = 503
+	ErrCodeGatewayTimeout     ErrCode = 504
+	ErrCodePanicErr           ErrCode = 590 // internal error, but panic error
 )
 
 type ErrCode int
@@ -28,15 +41,33 @@ func (p ErrCode) IsOk() bool {
 	return p == ErrCodeOk
 }
 
+// Error 使 ErrCode 可作为 errors.Is 的目标，如 errors.Is(err, gi.ErrCodeNotFound)
+// 输出与 int 相同，保证日志中 code 的格式不变
+func (p ErrCode) Error() string {
+	return strconv.Itoa(int(p))
+}
+
 func (p ErrCode) Value() int {
 	return int(p)
 }
 
+// Status 对应的 http 状态码，非标准 http 状态码（如 ErrCodePanicErr）一律视为 500
+func (p ErrCode) Status() int {
+	if p == ErrCodePanicErr || p < 100 || p > 599 {
+		return http.StatusInternalServerError
+	}
+	return int(p)
+}
+
 type CusError struct {
 	code    ErrCode
 	msg     string
 	context utee.J
 	err     error
+	biz     *BizCode
+	rmsg    redact.RedactableString // 由 NewCusErrorf/WrapCusErrf 创建时保留敏感标记
+	header  http.Header             // 随错误输出的响应头
+	fields  []FieldError            // 字段级的校验错误
 }
 
 func (p *CusError) Error() string {
@@ -59,8 +90,23 @@ func (e *CusError) Unwrap() error {
 	return e.err
 }
 
+// Is 按 ErrCode 或 BizCode 匹配
+func (p *CusError) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrCode:
+		return p.code == t
+	case *BizCode:
+		return p.biz != nil && p.biz == t
+	}
+	return false
+}
+
+// Msg 对客户端展示的提示信息，格式化参数中的敏感内容显示为 ‹×›
 func (p *CusError) Msg() string {
-	return p.msg
+	if p.rmsg == "" {
+		return p.msg
+	}
+	return string(p.rmsg.Redact())
 }
 
 func (p *CusError) Code() ErrCode {
@@ -71,18 +117,35 @@ func (p *CusError) Context() utee.J {
 	return p.context
 }
 
-func NewCusError(code ErrCode, msg string, contexts ...utee.J) error {
-	err := &CusError{
-		code: code,
-		msg:  msg,
-	}
-	if len(contexts) > 0 {
-		err.context = contexts[0]
+// Header 随错误输出的响应头，如 Retry-After、Allow
+func (p *CusError) Header() http.Header {
+	return p.header
+}
+
+// SetHeader 设置随错误输出的响应头
+func (p *CusError) SetHeader(key, value string) {
+	if p.header == nil {
+		p.header = http.Header{}
 	}
-	return errors.WithStack(err)
+	p.header.Set(key, value)
 }
 
-func WrapCusErr(code ErrCode, e error, msg string, contexts ...utee.J) error {
+// Fields 字段级的校验错误，渲染 problem+json 时作为 errors 字段输出
+func (p *CusError) Fields() []FieldError {
+	return p.fields
+}
+
+// SetFields 设置字段级的校验错误
+func (p *CusError) SetFields(fields []FieldError) {
+	p.fields = fields
+}
+
+// Biz 业务错误码，未指定时为 nil
+func (p *CusError) Biz() *BizCode {
+	return p.biz
+}
+
+func newCusError(code ErrCode, e error, msg string, contexts []utee.J) *CusError {
 	err := &CusError{
 		code: code,
 		msg:  msg,
@@ -94,42 +157,116 @@ func WrapCusErr(code ErrCode, e error, msg string, contexts ...utee.J) error {
 	return err
 }
 
+func NewCusError(code ErrCode, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(code, nil, msg, contexts), 1)
+}
+
+func WrapCusErr(code ErrCode, e error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(code, e, msg, contexts), 1)
+}
+
 func WrapNotModifiedCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeNotModified, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeNotModified, err, msg, contexts), 1)
 }
 
 func WrapBadRequestCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeBadReq, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeBadReq, err, msg, contexts), 1)
 }
 
 func WrapNotFoundCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeNotFound, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeNotFound, err, msg, contexts), 1)
 }
 
 func WrapForbiddenCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeForbidden, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeForbidden, err, msg, contexts), 1)
 }
 
 func WrapUnauthorizedCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeUnauthorized, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeUnauthorized, err, msg, contexts), 1)
 }
 
 func WrapInternalCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodeInternalErr, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodeInternalErr, err, msg, contexts), 1)
 }
 
 func WrapPanicCusError(err error, msg string, contexts ...utee.J) error {
-	return WrapCusErr(ErrCodePanicErr, err, msg, contexts...)
+	return errors.WithStackDepth(newCusError(ErrCodePanicErr, err, msg, contexts), 1)
+}
+
+func WrapConflictCusError(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(ErrCodeConflict, err, msg, contexts), 1)
+}
+
+func WrapGoneCusError(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(ErrCodeGone, err, msg, contexts), 1)
+}
+
+func WrapPreconditionFailedCusError(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(ErrCodePreconditionFailed, err, msg, contexts), 1)
+}
+
+func WrapPayloadTooLargeCusError(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(ErrCodePayloadTooLarge, err, msg, contexts), 1)
+}
+
+func WrapUnprocessableCusError(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(newCusError(ErrCodeUnprocessable, err, msg, contexts), 1)
+}
+
+// WrapMethodNotAllowedCusError 405， allow 为允许的方法，输出为 Allow 响应头
+func WrapMethodNotAllowedCusError(err error, allow []string, msg string, contexts ...utee.J) error {
+	ce := newCusError(ErrCodeMethodNotAllowed, err, msg, contexts)
+	ce.SetHeader("Allow", strings.Join(allow, ", "))
+	return errors.WithStackDepth(ce, 1)
 }
 
+// WrapTooManyRequestsCusError 429， retryAfter 大于 0 时输出 Retry-After 响应头
+func WrapTooManyRequestsCusError(err error, retryAfter time.Duration, msg string, contexts ...utee.J) error {
+	ce := newCusError(ErrCodeTooManyRequests, err, msg, contexts)
+	setRetryAfter(ce, retryAfter)
+	return errors.WithStackDepth(ce, 1)
+}
+
+// WrapServiceUnavailableCusError 503， retryAfter 大于 0 时输出 Retry-After 响应头
+func WrapServiceUnavailableCusError(err error, retryAfter time.Duration, msg string, contexts ...utee.J) error {
+	ce := newCusError(ErrCodeServiceUnavailable, err, msg, contexts)
+	setRetryAfter(ce, retryAfter)
+	return errors.WithStackDepth(ce, 1)
+}
+
+// setRetryAfter Retry-After 以秒为单位，向上取整
+func setRetryAfter(ce *CusError, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	ce.SetHeader("Retry-After", strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10))
+}
+
+// WithErrHeader 为错误链中的 CusError 设置响应头，err 不含 CusError 时原样返回
+func WithErrHeader(err error, key, value string) error {
+	if ce, ok := IsCusError(err); ok {
+		ce.SetHeader(key, value)
+	}
+	return err
+}
+
+// WithErrFields 为错误链中的 CusError 设置字段级的校验错误，err 不含 CusError 时原样返回
+func WithErrFields(err error, fields []FieldError) error {
+	if ce, ok := IsCusError(err); ok {
+		ce.SetFields(fields)
+	}
+	return err
+}
+
+// IsCusError 沿错误链查找 CusError， 支持 cockroachdb/errors 的各种包装及 fmt.Errorf("%w")
 func IsCusError(err error) (*CusError, bool) {
 	if err == nil {
 		return nil, false
 	}
 
-	e, ok := err.(*CusError)
-	if !ok {
-		return nil, ok
+	var e *CusError
+	if !errors.As(err, &e) {
+		return nil, false
 	}
 
 	return e, true
@@ -152,50 +289,43 @@ func HandleError(c *gin.Context, err error, lgs ...*log.Entry) bool {
 		return false
 	}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		c.String(http.StatusBadRequest, "没有找到记录")
-		c.Abort()
-		return true
-	}
+	// 未包装的错误经分类器归类， 下面统一处理
+	ce := ClassifyError(err)
 
-	// 测试模式下保持安静
-	if gin.Mode() != gin.TestMode {
-		fmt.Printf("%+v", err) // 打印到标准输出，方便查错
+	lg := log.NewEntry(log.StandardLogger())
+	if len(lgs) > 0 {
+		lg = lgs[0]
 	}
+	lg = lg.WithField("ip", c.ClientIP()).WithField("requestId", GetRequestId(c))
 
-	ce, ok := IsCusError(err)
-	if !ok {
-		// 如果是未包装错误， 现在包装， 下面统一处理
-		ce = WrapInternalCusError(err, "服务错误，请稍后重试").(*CusError)
+	if bc := ce.Biz(); bc != nil {
+		lg = lg.WithField("bizCode", bc.Code)
 	}
 
-	clientIP := c.ClientIP()
-	requestId := GetRequestId(c)
-	lg := errEntry(ce, lgs...).WithField("ip", clientIP).WithField("requestId", requestId)
-
-	for k, v := range ce.Context() {
-		lg = lg.WithField(k, v)
+	// 设置了内部日志时，完整内容输出到内部日志，常规日志只输出脱敏内容
+	if internal := getInternalLogger(); internal != nil {
+		logCusError(log.NewEntry(internal).WithFields(lg.Data), ce, fullErr(err), ce.Error(), contextValue)
+		logCusError(lg, ce, redactedErr(err), ce.Redacted(), redactedValue)
+	} else {
+		logCusError(lg, ce, fullErr(err), ce.Error(), contextValue)
 	}
 
-	outMsg := func() string {
-		if ce.Code() >= 500 {
-			return "panic " + ce.Error()
-		} else {
-			return ce.Error()
-		}
-	}
+	reportError(c, err, ce)
 
-	lg.Errorln(outMsg())
+	renderError(c, ce)
+	return true
+}
 
-	if ce.Code() > 500 {
-		c.String(http.StatusInternalServerError, ce.Msg())
-		c.Abort()
-		return true
+func logCusError(lg *log.Entry, ce *CusError, errStr, msg string, value func(any) any) {
+	lg = lg.WithField("err", errStr)
+	for k, v := range ce.Context() {
+		lg = lg.WithField(k, value(v))
 	}
 
-	c.String(int(ce.Code()), ce.Msg())
-	c.Abort()
-	return true
+	if ce.Code() >= 500 {
+		msg = "panic " + msg
+	}
+	lg.Errorln(msg)
 }
 
 func errEntry(err error, vs ...*log.Entry) *log.Entry {
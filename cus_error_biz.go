@@ -0,0 +1,149 @@
+package gi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/quexer/utee"
+)
+
+// HeaderErrorCode 输出业务错误码的响应头，纯文本响应的客户端也可据此区分错误
+const HeaderErrorCode = "X-Error-Code"
+
+// BizCode 业务错误码，由各模块通过 RegisterBizCode 声明
+type BizCode struct {
+	Module    string  `json:"module"`    // 所属模块，作为命名空间
+	Name      string  `json:"name"`      // 错误名，如 ORDER_STOCK_EMPTY
+	Code      int     `json:"code"`      // 业务码，全局唯一，如 40021
+	Status    ErrCode `json:"status"`    // 对应的 http 状态
+	Msg       string  `json:"msg"`       // 默认提示信息
+	Retryable bool    `json:"retryable"` // 客户端是否可以重试
+}
+
+// BizCodeOpt 业务码的可选配置
+type BizCodeOpt func(*BizCode)
+
+// BizCodeWithRetryable 标记该错误可重试
+func BizCodeWithRetryable() BizCodeOpt {
+	return func(p *BizCode) {
+		p.Retryable = true
+	}
+}
+
+var bizCodes = struct {
+	sync.RWMutex
+	byCode map[int]*BizCode
+	byName map[string]*BizCode
+}{
+	byCode: map[int]*BizCode{},
+	byName: map[string]*BizCode{},
+}
+
+// RegisterBizCode 注册业务错误码， 通常在包级变量中声明
+// code 或 module+name 重复时 panic，以便在启动阶段暴露冲突
+func RegisterBizCode(module, name string, code int, status ErrCode, msg string, opt ...BizCodeOpt) *BizCode {
+	bc := &BizCode{
+		Module: module,
+		Name:   name,
+		Code:   code,
+		Status: status,
+		Msg:    msg,
+	}
+	for _, v := range opt {
+		v(bc)
+	}
+
+	bizCodes.Lock()
+	defer bizCodes.Unlock()
+
+	if exist, ok := bizCodes.byCode[code]; ok {
+		panic(fmt.Sprintf("biz code %d already registered by %s", code, exist.FullName()))
+	}
+	if _, ok := bizCodes.byName[bc.FullName()]; ok {
+		panic(fmt.Sprintf("biz code %s already registered", bc.FullName()))
+	}
+
+	bizCodes.byCode[code] = bc
+	bizCodes.byName[bc.FullName()] = bc
+	return bc
+}
+
+// FullName 带命名空间的错误名，如 order.ORDER_STOCK_EMPTY
+func (p *BizCode) FullName() string {
+	if p.Module == "" {
+		return p.Name
+	}
+	return p.Module + "." + p.Name
+}
+
+// Error 使 BizCode 可作为 errors.Is 的目标，如 errors.Is(err, ErrStockEmpty)
+func (p *BizCode) Error() string {
+	return p.FullName()
+}
+
+// New 创建携带此业务码的 CusError， 使用默认提示信息
+func (p *BizCode) New(contexts ...utee.J) error {
+	return errors.WithStackDepth(p.cusError(nil, p.Msg, contexts), 1)
+}
+
+// NewMsg 创建携带此业务码的 CusError， 使用指定的提示信息
+func (p *BizCode) NewMsg(msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(p.cusError(nil, msg, contexts), 1)
+}
+
+// Wrap 包装 err， 使用默认提示信息
+func (p *BizCode) Wrap(err error, contexts ...utee.J) error {
+	return errors.WithStackDepth(p.cusError(err, p.Msg, contexts), 1)
+}
+
+// WrapMsg 包装 err， 使用指定的提示信息
+func (p *BizCode) WrapMsg(err error, msg string, contexts ...utee.J) error {
+	return errors.WithStackDepth(p.cusError(err, msg, contexts), 1)
+}
+
+func (p *BizCode) cusError(err error, msg string, contexts []utee.J) *CusError {
+	ce := newCusError(p.Status, err, msg, contexts)
+	ce.biz = p
+	return ce
+}
+
+// GetBizCode 按业务码查找已注册的 BizCode
+func GetBizCode(code int) (*BizCode, bool) {
+	bizCodes.RLock()
+	defer bizCodes.RUnlock()
+
+	bc, ok := bizCodes.byCode[code]
+	return bc, ok
+}
+
+// BizCodes 全部已注册的业务码，按 code 升序
+func BizCodes() []*BizCode {
+	bizCodes.RLock()
+	l := make([]*BizCode, 0, len(bizCodes.byCode))
+	for _, v := range bizCodes.byCode {
+		l = append(l, v)
+	}
+	bizCodes.RUnlock()
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Code < l[j].Code
+	})
+	return l
+}
+
+// BizCodeCatalog 以 JSON 导出全部业务码，供前端生成错误码表
+func BizCodeCatalog() ([]byte, error) {
+	return json.MarshalIndent(BizCodes(), "", "  ")
+}
+
+// HdlBizCodes 输出业务码目录的handler
+func HdlBizCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, BizCodes())
+	}
+}
@@ -0,0 +1,168 @@
+package gi
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"gorm.io/gorm"
+)
+
+// ErrClassifier 将任意错误归类为 CusError，不能识别时返回 false
+type ErrClassifier func(err error) (*CusError, bool)
+
+var errClassifiers struct {
+	sync.RWMutex
+	list []ErrClassifier
+}
+
+// RegisterErrClassifier 注册自定义错误分类器
+// 按注册顺序执行，均先于内置的 context、gorm 分类器
+func RegisterErrClassifier(fn ...ErrClassifier) {
+	errClassifiers.Lock()
+	defer errClassifiers.Unlock()
+	errClassifiers.list = append(errClassifiers.list, fn...)
+}
+
+// ClassifyError 将 err 归类为 CusError
+// 已是 CusError 的直接返回，其次依次尝试自定义分类器与内置分类器，都不能识别的归为 500
+func ClassifyError(err error) *CusError {
+	if ce, ok := IsCusError(err); ok {
+		return ce
+	}
+
+	errClassifiers.RLock()
+	list := errClassifiers.list
+	errClassifiers.RUnlock()
+
+	for _, fn := range list {
+		if ce, ok := fn(err); ok {
+			return ce
+		}
+	}
+
+	for _, fn := range []ErrClassifier{classifyContext, classifyGorm} {
+		if ce, ok := fn(err); ok {
+			return ce
+		}
+	}
+
+	return &CusError{code: ErrCodeInternalErr, msg: "服务错误，请稍后重试", err: err}
+}
+
+// classifyContext 请求超时与客户端取消
+func classifyContext(err error) (*CusError, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return &CusError{code: ErrCodeGatewayTimeout, msg: "请求超时，请稍后重试", err: err}, true
+	case errors.Is(err, context.Canceled):
+		return &CusError{code: ErrCodeClientClosed, msg: "请求已取消", err: err}, true
+	}
+	return nil, false
+}
+
+// errMapping 错误到 ErrCode 及提示信息的映射
+type errMapping struct {
+	code ErrCode
+	msg  string
+}
+
+func (p errMapping) wrap(err error) (*CusError, bool) {
+	return &CusError{code: p.code, msg: p.msg, err: err}, true
+}
+
+type gormConfig struct {
+	notFound   errMapping
+	duplicated errMapping
+	foreignKey errMapping
+}
+
+// GormOpt 内置 gorm 分类器的配置项
+type GormOpt func(*gormConfig)
+
+// GormWithNotFound gorm.ErrRecordNotFound 的映射，默认为 404
+func GormWithNotFound(code ErrCode, msg string) GormOpt {
+	return func(cfg *gormConfig) {
+		cfg.notFound = errMapping{code: code, msg: msg}
+	}
+}
+
+// GormWithDuplicatedKey 唯一键冲突的映射，默认为 409
+func GormWithDuplicatedKey(code ErrCode, msg string) GormOpt {
+	return func(cfg *gormConfig) {
+		cfg.duplicated = errMapping{code: code, msg: msg}
+	}
+}
+
+// GormWithForeignKey 外键约束失败的映射，默认为 409， 也可按需改为 422
+func GormWithForeignKey(code ErrCode, msg string) GormOpt {
+	return func(cfg *gormConfig) {
+		cfg.foreignKey = errMapping{code: code, msg: msg}
+	}
+}
+
+var gormCfg = struct {
+	sync.RWMutex
+	gormConfig
+}{
+	gormConfig: gormConfig{
+		notFound:   errMapping{code: ErrCodeNotFound, msg: "没有找到记录"},
+		duplicated: errMapping{code: ErrCodeConflict, msg: "记录已存在"},
+		foreignKey: errMapping{code: ErrCodeConflict, msg: "关联数据不存在或仍被引用"},
+	},
+}
+
+// SetGormClassifier 调整内置 gorm 分类器的映射
+func SetGormClassifier(opt ...GormOpt) {
+	gormCfg.Lock()
+	defer gormCfg.Unlock()
+	for _, v := range opt {
+		v(&gormCfg.gormConfig)
+	}
+}
+
+// classifyGorm gorm 错误， 包括未开启 TranslateError 时 mysql、postgres 驱动的原始错误
+func classifyGorm(err error) (*CusError, bool) {
+	gormCfg.RLock()
+	cfg := gormCfg.gormConfig
+	gormCfg.RUnlock()
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return cfg.notFound.wrap(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return cfg.duplicated.wrap(err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return cfg.foreignKey.wrap(err)
+	}
+
+	switch driverErrCode(err) {
+	case "23505", "1062": // unique_violation, ER_DUP_ENTRY
+		return cfg.duplicated.wrap(err)
+	case "23503", "1451", "1452": // foreign_key_violation, ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		return cfg.foreignKey.wrap(err)
+	}
+	return nil, false
+}
+
+// driverErrCode 从驱动错误中取错误码， postgres 为 SQLState， mysql 为 Error Number
+// 通过接口及错误信息识别，避免依赖具体驱动
+func driverErrCode(err error) string {
+	var pg interface{ SQLState() string }
+	if errors.As(err, &pg) {
+		return pg.SQLState()
+	}
+
+	for e := err; e != nil; e = errors.UnwrapOnce(e) {
+		// mysql: "Error 1062 (23000): Duplicate entry ..." 或 "Error 1062: Duplicate entry ..."
+		msg, ok := strings.CutPrefix(e.Error(), "Error ")
+		if !ok || len(msg) < 4 {
+			continue
+		}
+		if code, _, ok := strings.Cut(msg, " "); ok {
+			return strings.TrimSuffix(code, ":")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package gi
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/redact"
+	"github.com/quexer/utee"
+	log "github.com/sirupsen/logrus"
+)
+
+// Safe 标记 context 值或消息参数不含敏感信息，可原样输出到脱敏日志
+// 未标记的 context 值及消息参数均视为敏感， 脱敏后显示为 ‹×›
+func Safe(v any) redact.SafeValue {
+	return redact.Safe(v)
+}
+
+// Unsafe 显式标记为敏感，用于原本会被视为安全的值
+func Unsafe(v any) any {
+	return redact.Unsafe(v)
+}
+
+// NewCusErrorf 以格式化方式创建 CusError，参数默认视为敏感，可用 Safe 标记
+// 如 NewCusErrorf(ErrCodeConflict, "手机号 %s 已注册", phone)，客户端及脱敏日志中看到的是 "手机号 ‹×› 已注册"
+func NewCusErrorf(code ErrCode, format string, args ...any) error {
+	return errors.WithStackDepth(newCusErrorf(code, nil, format, args), 1)
+}
+
+// WrapCusErrf 以格式化方式包装 err，参数规则同 NewCusErrorf
+func WrapCusErrf(code ErrCode, e error, format string, args ...any) error {
+	return errors.WithStackDepth(newCusErrorf(code, e, format, args), 1)
+}
+
+func newCusErrorf(code ErrCode, e error, format string, args []any) *CusError {
+	rmsg := redact.Sprintf(format, args...)
+	ce := newCusError(code, e, rmsg.StripMarkers(), nil)
+	ce.rmsg = rmsg
+	return ce
+}
+
+// redactableMsg 普通构造函数传入的 msg 由开发者给定，视为安全
+func (p *CusError) redactableMsg() redact.RedactableString {
+	if p.rmsg != "" {
+		return p.rmsg
+	}
+	return redact.Sprint(redact.Safe(p.msg))
+}
+
+// SafeFormatError 实现 errors.SafeFormatter， 使 CusError 可被 cockroachdb/errors 脱敏输出
+func (p *CusError) SafeFormatError(pr errors.Printer) (next error) {
+	pr.Print(p.redactableMsg())
+	if len(p.context) > 0 {
+		pr.Print(redact.SafeString(":map["))
+		for i, k := range sortedKeys(p.context) {
+			if i > 0 {
+				pr.Print(redact.SafeString(" "))
+			}
+			pr.Printf("%s:%v", redact.SafeString(k), redactable(p.context[k]))
+		}
+		pr.Print(redact.SafeString("]"))
+	}
+	return p.err
+}
+
+// Redacted 脱敏后的完整错误描述，包括 context 及被包装的错误，敏感内容显示为 ‹×›
+func (p *CusError) Redacted() string {
+	return string(redact.Sprint(p).Redact())
+}
+
+// redactable 转换为 redact 能识别的值，Public 的值视为安全
+func redactable(v any) any {
+	if pv, ok := v.(PublicValue); ok {
+		return redact.Safe(pv.V)
+	}
+	if _, ok := v.(redact.SafeValue); ok {
+		return v
+	}
+	return redact.Unsafe(v)
+}
+
+// contextValue 去掉 Safe/Public 等标记，取原始值
+func contextValue(v any) any {
+	switch x := v.(type) {
+	case PublicValue:
+		return x.V
+	case interface{ GetValue() interface{} }:
+		return x.GetValue()
+	}
+	return v
+}
+
+// redactedValue 脱敏后的 context 值
+func redactedValue(v any) any {
+	r := redactable(v)
+	if _, ok := r.(redact.SafeValue); ok {
+		return contextValue(v)
+	}
+	return string(redact.RedactedMarker())
+}
+
+func sortedKeys(m utee.J) []string {
+	l := make([]string, 0, len(m))
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+var internalLog struct {
+	sync.RWMutex
+	logger *log.Logger
+}
+
+// SetInternalLogger 设置保存完整错误详情的内部日志
+// 设置后 HandleError 在常规日志中只输出脱敏内容，完整内容（含敏感信息）输出到此日志
+// 未设置时常规日志输出完整内容
+func SetInternalLogger(l *log.Logger) {
+	internalLog.Lock()
+	defer internalLog.Unlock()
+	internalLog.logger = l
+}
+
+func getInternalLogger() *log.Logger {
+	internalLog.RLock()
+	defer internalLog.RUnlock()
+	return internalLog.logger
+}
+
+// redactedErr 脱敏后的错误，供常规日志使用
+func redactedErr(err error) string {
+	return string(redact.Sprint(err).Redact())
+}
+
+// fullErr 完整的错误，供内部日志使用
+func fullErr(err error) string {
+	return fmt.Sprintf("%+v", err)
+}
@@ -0,0 +1,205 @@
+package gi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/quexer/utee"
+	log "github.com/sirupsen/logrus"
+)
+
+// MIMEProblemJSON RFC 7807 错误响应的 Content-Type
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem RFC 7807 格式的错误响应体
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestId string `json:"requestId,omitempty"`
+
+	// Extensions 扩展字段，与上面的标准字段平铺输出，同名时以标准字段为准
+	Extensions map[string]any `json:"-"`
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+6)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	if p.RequestId != "" {
+		m["requestId"] = p.RequestId
+	}
+	return json.Marshal(m)
+}
+
+// PublicValue 可对客户端公开的 context 值
+type PublicValue struct {
+	V any
+}
+
+// Public 标记 CusError context 中的值可公开，渲染 problem+json 时会作为扩展字段输出
+// 未标记的 context 值只会出现在日志中
+func Public(v any) PublicValue {
+	return PublicValue{V: v}
+}
+
+func (p PublicValue) String() string {
+	return fmt.Sprint(p.V)
+}
+
+func (p PublicValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.V)
+}
+
+// NewProblem 根据 CusError 构造 Problem
+func NewProblem(c *gin.Context, ce *CusError) Problem {
+	status := ce.Code().Status()
+	p := Problem{
+		Type:      "about:blank",
+		Title:     statusText(status),
+		Status:    status,
+		Detail:    ce.Msg(),
+		Instance:  c.Request.URL.Path,
+		RequestId: GetRequestId(c),
+	}
+
+	for k, v := range ce.Context() {
+		pv, ok := v.(PublicValue)
+		if !ok {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = map[string]any{}
+		}
+		p.Extensions[k] = pv.V
+	}
+
+	if fields := ce.Fields(); len(fields) > 0 {
+		if p.Extensions == nil {
+			p.Extensions = map[string]any{}
+		}
+		p.Extensions["errors"] = fields
+	}
+
+	if bc := ce.Biz(); bc != nil {
+		if p.Extensions == nil {
+			p.Extensions = map[string]any{}
+		}
+		p.Extensions["code"] = bc.Code
+		p.Extensions["codeName"] = bc.FullName()
+		p.Extensions["retryable"] = bc.Retryable
+	}
+	return p
+}
+
+// statusText 补充 http 包中没有的状态码描述
+func statusText(status int) string {
+	if status == int(ErrCodeClientClosed) {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// renderError 所有错误响应的唯一出口，输出后中止请求
+// 客户端 Accept 明确接受 json 时输出 application/problem+json，明确接受 yaml、toml、msgpack 时以该格式输出同样的内容
+// 否则（包括未指定 Accept）输出纯文本
+func renderError(c *gin.Context, ce *CusError) {
+	status := ce.Code().Status()
+
+	for k, v := range ce.Header() {
+		c.Writer.Header()[k] = v
+	}
+	if bc := ce.Biz(); bc != nil {
+		c.Header(HeaderErrorCode, strconv.Itoa(bc.Code))
+	}
+
+	offered := []string{gin.MIMEPlain, MIMEProblemJSON, gin.MIMEJSON}
+	for _, f := range bodyFormats {
+		offered = append(offered, f.mime)
+	}
+
+	format := c.NegotiateFormat(offered...)
+	switch format {
+	case MIMEProblemJSON, gin.MIMEJSON:
+		c.Header("Content-Type", MIMEProblemJSON)
+		c.JSON(status, NewProblem(c, ce))
+	default:
+		f, ok := findFormat(format)
+		if !ok {
+			c.String(status, ce.Msg())
+			break
+		}
+		v, err := NewProblem(c, ce).plain()
+		if err != nil {
+			log.WithError(err).Errorln("encode problem failed")
+			c.String(status, ce.Msg())
+			break
+		}
+		c.Render(status, f.render(v))
+	}
+	c.Abort()
+}
+
+// plain 转换为只含基本类型、map 及 slice 的值，字段名与 json 一致，供 yaml、toml、msgpack 输出
+// null 值被去掉，toml 不支持
+func (p Problem) plain() (any, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return plainValue(v), nil
+}
+
+// plainValue 将 json.Number 转为 int64 或 float64，并去掉 map 中的 null
+func plainValue(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, e := range t {
+			if e == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = plainValue(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = plainValue(e)
+		}
+	}
+	return v
+}
+
+// abortBadRequest 以 400 输出错误
+func abortBadRequest(c *gin.Context, err error, msg string, contexts ...utee.J) {
+	renderError(c, newCusError(ErrCodeBadReq, err, msg, contexts))
+}
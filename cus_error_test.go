@@ -0,0 +1,108 @@
+package gi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+)
+
+var testBizStockEmpty = RegisterBizCode("test", "STOCK_EMPTY", 99001, ErrCodeConflict, "库存不足")
+
+func TestCusErrorChain(t *testing.T) {
+	base := NewCusError(ErrCodeNotFound, "订单不存在")
+	biz := testBizStockEmpty.New()
+
+	wraps := []struct {
+		name string
+		wrap func(error) error
+	}{
+		{"direct", func(err error) error { return err }},
+		{"fmt %w", func(err error) error { return fmt.Errorf("load order: %w", err) }},
+		{"errors.Wrap", func(err error) error { return errors.Wrap(err, "load order") }},
+		{"errors.WithMessage", func(err error) error { return errors.WithMessage(err, "load order") }},
+		{"errors.WithStack", func(err error) error { return errors.WithStack(err) }},
+		{"nested", func(err error) error {
+			err = errors.Wrap(err, "repo")
+			err = fmt.Errorf("service: %w", err)
+			err = errors.WithMessage(err, "handler")
+			return errors.Wrapf(err, "level %d", 4)
+		}},
+	}
+
+	for _, w := range wraps {
+		t.Run(w.name, func(t *testing.T) {
+			err := w.wrap(base)
+			ce, ok := IsCusError(err)
+			if !ok {
+				t.Fatalf("IsCusError(%v) = false", err)
+			}
+			if ce.Code() != ErrCodeNotFound {
+				t.Errorf("Code() = %v, want %v", ce.Code(), ErrCodeNotFound)
+			}
+			if got := GetErrorMsg(err); got != "订单不存在" {
+				t.Errorf("GetErrorMsg() = %q, want %q", got, "订单不存在")
+			}
+			if !errors.Is(err, ErrCodeNotFound) {
+				t.Errorf("errors.Is(err, ErrCodeNotFound) = false")
+			}
+			if errors.Is(err, ErrCodeBadReq) {
+				t.Errorf("errors.Is(err, ErrCodeBadReq) = true")
+			}
+			if errors.Is(err, testBizStockEmpty) {
+				t.Errorf("errors.Is(err, bizCode) = true for plain CusError")
+			}
+
+			err = w.wrap(biz)
+			ce, ok = IsCusError(err)
+			if !ok {
+				t.Fatalf("IsCusError(%v) = false", err)
+			}
+			if ce.Biz() != testBizStockEmpty {
+				t.Errorf("Biz() = %v, want %v", ce.Biz(), testBizStockEmpty)
+			}
+			if got := GetErrorMsg(err); got != "库存不足" {
+				t.Errorf("GetErrorMsg() = %q, want %q", got, "库存不足")
+			}
+			if !errors.Is(err, testBizStockEmpty) {
+				t.Errorf("errors.Is(err, bizCode) = false")
+			}
+			if !errors.Is(err, ErrCodeConflict) {
+				t.Errorf("errors.Is(err, ErrCodeConflict) = false")
+			}
+		})
+	}
+}
+
+func TestCusErrorWrapsCause(t *testing.T) {
+	cause := errors.New("db down")
+	err := fmt.Errorf("outer: %w", errors.Wrap(WrapInternalCusError(cause, "服务繁忙"), "repo"))
+
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(err, cause) = false")
+	}
+	if !errors.Is(err, ErrCodeInternalErr) {
+		t.Errorf("errors.Is(err, ErrCodeInternalErr) = false")
+	}
+	if got := GetErrorMsg(err); got != "服务繁忙" {
+		t.Errorf("GetErrorMsg() = %q, want %q", got, "服务繁忙")
+	}
+}
+
+func TestIsCusErrorNotFound(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		errors.New("plain"),
+		fmt.Errorf("wrapped: %w", errors.New("plain")),
+	} {
+		if _, ok := IsCusError(err); ok {
+			t.Errorf("IsCusError(%v) = true", err)
+		}
+	}
+	if got := GetErrorMsg(nil); got != "" {
+		t.Errorf("GetErrorMsg(nil) = %q", got)
+	}
+	if got := GetErrorMsg(errors.New("plain")); got != "plain" {
+		t.Errorf("GetErrorMsg() = %q, want %q", got, "plain")
+	}
+}
@@ -13,7 +13,7 @@ type GinOption func(*gin.Engine)
 func New(opt ...GinOption) *gin.Engine {
 	binding.Validator = new(defaultValidator)
 
-	if err := initTrans(ZH); err != nil {
+	if err := initTrans(); err != nil {
 		log.WithError(err).Errorln("init trans failed")
 	}
 
@@ -27,6 +27,13 @@ func New(opt ...GinOption) *gin.Engine {
 	return r
 }
 
+// WithLocale 设置默认语言，请求未协商出语言时使用，见 MidLocale
+func WithLocale(locale Locale) GinOption {
+	return func(*gin.Engine) {
+		SetDefaultLocale(locale)
+	}
+}
+
 // WithStatic 服务静态文件
 // 默认url前缀为 /，本地文件路径为 ./public，自动索引
 // 如果需要自定义 ，可使用 gi.Static middleware 配合 gi.StaticWithXxxOption 使用
@@ -35,9 +42,21 @@ func WithStatic() GinOption {
 }
 
 // WithPprof 启用pprof
-func WithPprof() GinOption {
+// guard 为可选的访问控制，如 MidDebugGuard(DebugGuardWithToken(token))
+func WithPprof(guard ...gin.HandlerFunc) GinOption {
+	return func(router *gin.Engine) {
+		pprof.Register(router.Group("", guard...))
+	}
+}
+
+// WithMethodNotAllowed 路由存在但方法不匹配时返回 405 并带上 Allow 响应头，而不是 404
+func WithMethodNotAllowed() GinOption {
 	return func(router *gin.Engine) {
-		pprof.Register(router)
+		router.HandleMethodNotAllowed = true
+		router.NoMethod(func(c *gin.Context) {
+			// Allow 响应头已由 gin 设置
+			renderError(c, newCusError(ErrCodeMethodNotAllowed, nil, "不支持的请求方法", nil))
+		})
 	}
 }
 
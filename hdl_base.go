@@ -2,11 +2,10 @@ package gi
 
 import (
 	"context"
-	"net/http"
-	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/cockroachdb/errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -25,6 +24,11 @@ func (p *BaseHdl) GetContext(c *gin.Context) context.Context {
 	return GetContext(c)
 }
 
+// GetLocale 当前请求的语言， 见 MidLocale
+func (p *BaseHdl) GetLocale(c *gin.Context) Locale {
+	return GetLocale(c)
+}
+
 func (p *BaseHdl) Copy(toValue interface{}, fromValue interface{}) error {
 	err := copier.Copy(toValue, fromValue)
 	if err != nil {
@@ -34,70 +38,85 @@ func (p *BaseHdl) Copy(toValue interface{}, fromValue interface{}) error {
 	return nil
 }
 
+// ParseIntQuery 取 int 类型的 query 参数，见 QueryArg
 func (p *BaseHdl) ParseIntQuery(c *gin.Context, key string) (int, bool) {
-	s := c.Query(key)
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		log.WithField("key", key).WithField("value", s).Errorln("bad int query")
-		c.String(http.StatusBadRequest, "参数错误")
-		c.Abort()
-		return 0, false
-	}
-
-	return i, true
+	return QueryArg[int](c, key)
 }
 
 func (p *BaseHdl) HandleError(c *gin.Context, err error, lgs ...*log.Entry) bool {
 	return HandleError(c, err, lgs...)
 }
 
+// ParseIntParam 取 int 类型的路径参数，见 PathArg
 func (p *BaseHdl) ParseIntParam(c *gin.Context, key string) (int, bool) {
-	s := c.Param(key)
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		log.WithField("key", key).WithField("value", s).Errorln("bad int param")
-		c.String(http.StatusBadRequest, "参数错误")
-		c.Abort()
-		return 0, false
-	}
-
-	return i, true
+	return PathArg[int](c, key)
 }
 
+// Binding 绑定并校验请求参数，失败时输出 400
+// 请求体按 Content-Type 选择解码器，支持 json、xml、msgpack、protobuf、yaml、toml 及表单
+// 校验错误的提示按行拼接；客户端接受 json 时，problem+json 中的 errors 字段为每个字段的详细信息，见 FieldError
+// tag 校验通过后，obj 实现了 ValidatorCtx 或 Validator 时自动调用，无需再调用 Valid
+// 启用了 MidStrictJSON 时 json 请求按严格模式解码，见 StrictJSON；请求中没有的字段取 default tag 的值，见 applyDefaults
+// query 中的数组、方括号 map 及嵌套对象需显式传入 Query，如 h.Binding(c, &req, gi.Query)
 func (p *BaseHdl) Binding(c *gin.Context, obj interface{}, b ...binding.Binding) bool {
-	var err error
-	if len(b) == 0 {
-		err = c.Bind(obj)
-	} else {
-		err = c.MustBindWith(obj, b[0])
+	bb := binding.Default(c.Request.Method, c.ContentType())
+	if len(b) > 0 {
+		bb = b[0]
 	}
+	bb = strictBinding(c, bb)
 
-	if err == nil {
-		return true
+	applyDefaults(obj)
+	if err := c.ShouldBindWith(obj, bb); err != nil {
+		log.WithError(err).
+			WithField("requestId", GetRequestId(c)).
+			Errorln("bind error")
+
+		abortBindError(c, err, obj, bb)
+		return false
 	}
 
-	log.WithError(err).
-		WithField("requestId", GetRequestId(c)).
-		Errorln("bind error")
-
-	var msg string
-	errs, ok := err.(validator.ValidationErrors)
-	if ok {
-		ret := Translate(errs)
-		var arr []string
-		for _, v := range ret {
-			arr = append(arr, lowerFirst(v))
+	return validObj(c, obj)
+}
+
+// abortBindError 校验错误及可识别的解码错误翻译为字段级的提示，其它错误原样输出
+func abortBindError(c *gin.Context, err error, obj interface{}, b binding.Binding) {
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		fields := TranslateFields(errs, TransWithLocale(GetLocale(c)), TransWithBinding(obj, b))
+		for i, v := range fields {
+			fields[i].Message = lowerGoName(v)
 		}
+		renderError(c, fieldsCusError(err, fields))
+		return
+	}
 
-		msg = strings.Join(arr, "\n")
-	} else {
-		msg = err.Error()
+	if ce := bindErrorCusError(c, err, obj, b); ce != nil {
+		renderError(c, ce)
+		return
 	}
 
-	c.String(http.StatusBadRequest, msg)
-	c.Abort()
-	return false
+	abortBadRequest(c, err, err.Error())
+}
 
+// fieldsCusError 字段级错误的 400，提示按行拼接
+func fieldsCusError(err error, fields []FieldError) *CusError {
+	arr := make([]string, 0, len(fields))
+	for _, v := range fields {
+		arr = append(arr, v.Message)
+	}
+
+	ce := newCusError(ErrCodeBadReq, err, strings.Join(arr, "\n"), nil)
+	ce.SetFields(fields)
+	return ce
+}
+
+// lowerGoName 提示以 Go 字段名开头（字段没有 tag 名称及 label）时首字母转小写，tag 名称及 label 保持原样
+func lowerGoName(fe FieldError) string {
+	name, _, _ := strings.Cut(fe.Field[strings.LastIndex(fe.Field, ".")+1:], "[")
+	if name == "" || !strings.HasPrefix(fe.Message, name) {
+		return fe.Message
+	}
+	return lowerFirst(fe.Message)
 }
 
 // lowerFirst 首字母转小写
@@ -115,24 +134,43 @@ func lowerFirst(str string) string {
 	return string(r)
 }
 
+// Valid 调用 v.Valid()，失败时输出错误，见 validObj
 func (p *BaseHdl) Valid(c *gin.Context, v Validator) bool {
-	if err := v.Valid(); err != nil {
-		log.WithError(err).Errorln("valid form error")
-
-		e, ok := IsCusError(err)
-		if ok {
-			c.String(http.StatusBadRequest, e.Msg())
-		} else {
-			c.String(http.StatusBadRequest, err.Error())
-		}
+	return validObj(c, v)
+}
 
-		c.Abort()
+// validObj obj 实现了 ValidatorCtx 或 Validator 时调用，失败时输出错误
+// 返回 CusError 时按其错误码输出，返回 validator.ValidationErrors 时与 tag 校验的输出一致，其它错误输出 400
+func validObj(c *gin.Context, obj interface{}) bool {
+	var err error
+	switch v := obj.(type) {
+	case ValidatorCtx:
+		err = v.Valid(GetContext(c))
+	case Validator:
+		err = v.Valid()
+	default:
+		return true
+	}
+	if err == nil {
+		return true
+	}
+
+	if _, ok := IsCusError(err); ok {
+		HandleError(c, err)
 		return false
 	}
 
-	return true
+	log.WithError(err).Errorln("valid form error")
+	abortBindError(c, err, obj, nil)
+	return false
 }
 
+// Validator 绑定参数后的自定义校验
 type Validator interface {
 	Valid() error
 }
+
+// ValidatorCtx 需要请求 context 的自定义校验，如查询数据库、取当前用户
+type ValidatorCtx interface {
+	Valid(ctx context.Context) error
+}
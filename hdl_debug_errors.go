@@ -0,0 +1,246 @@
+package gi
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	debugErrorsMaxGroups     = 500 // 最多保留的分组数，超出时淘汰最久未出现的
+	debugErrorsMaxRequestIds = 10  // 每组保留的最近 requestId 个数
+)
+
+// ErrorGroup 按 fingerprint 聚合的一组错误
+type ErrorGroup struct {
+	Fingerprint string         `json:"fingerprint"`
+	Kind        string         `json:"kind"`
+	Code        ErrCode        `json:"code"`
+	Msg         string         `json:"msg"`   // 已脱敏的消息模板
+	Frame       string         `json:"frame"` // 第一个业务帧
+	Route       string         `json:"route"`
+	Count       int64          `json:"count"`
+	FirstSeen   time.Time      `json:"firstSeen"`
+	LastSeen    time.Time      `json:"lastSeen"`
+	RequestIds  []string       `json:"requestIds"` // 最近的 requestId，新的在前
+	Context     map[string]any `json:"context"`    // 最近一次的 context，已脱敏
+}
+
+// errorAggregator 在内存中按 fingerprint 聚合 5xx 错误及 panic，作为 Reporter 注册
+type errorAggregator struct {
+	mu     sync.Mutex
+	groups map[string]*ErrorGroup
+}
+
+func newErrorAggregator() *errorAggregator {
+	return &errorAggregator{groups: map[string]*ErrorGroup{}}
+}
+
+func (p *errorAggregator) Report(r *Report) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g, ok := p.groups[r.Fingerprint]
+	if !ok {
+		if len(p.groups) >= debugErrorsMaxGroups {
+			p.evict()
+		}
+		g = &ErrorGroup{
+			Fingerprint: r.Fingerprint,
+			Kind:        r.Kind,
+			Code:        r.Code,
+			Msg:         r.Msg,
+			Route:       r.Request.Route,
+			FirstSeen:   r.Time,
+		}
+		if f, ok := topInAppFrame(r.Frames); ok {
+			g.Frame = fmt.Sprintf("%s %s:%d", shortFuncName(f.Function), f.File, f.Line)
+		}
+		p.groups[r.Fingerprint] = g
+	}
+
+	g.Count++
+	g.LastSeen = r.Time
+	if r.Request.RequestId != "" {
+		g.RequestIds = append([]string{r.Request.RequestId}, g.RequestIds...)
+		if len(g.RequestIds) > debugErrorsMaxRequestIds {
+			g.RequestIds = g.RequestIds[:debugErrorsMaxRequestIds]
+		}
+	}
+
+	g.Context = map[string]any{}
+	for k, v := range r.Context {
+		g.Context[k] = redactedValue(v)
+	}
+}
+
+// evict 淘汰最久未出现的一组
+func (p *errorAggregator) evict() {
+	var oldest *ErrorGroup
+	for _, v := range p.groups {
+		if oldest == nil || v.LastSeen.Before(oldest.LastSeen) {
+			oldest = v
+		}
+	}
+	if oldest != nil {
+		delete(p.groups, oldest.Fingerprint)
+	}
+}
+
+// list 按最近出现时间倒序
+func (p *errorAggregator) list() []ErrorGroup {
+	p.mu.Lock()
+	l := make([]ErrorGroup, 0, len(p.groups))
+	for _, v := range p.groups {
+		g := *v
+		g.RequestIds = append([]string(nil), v.RequestIds...)
+		l = append(l, g)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].LastSeen.After(l[j].LastSeen)
+	})
+	return l
+}
+
+func (p *errorAggregator) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.groups = map[string]*ErrorGroup{}
+}
+
+// WithDebugErrors 在内存中聚合 5xx 错误及 panic，通过 /debug/errors 查看
+// 默认输出 HTML，Accept 为 json 或带 format=json 参数时输出 JSON；DELETE 清空
+// guard 为访问控制，与 WithPprof 使用同一个即可；未指定时使用不带配置的 MidDebugGuard，拒绝所有访问
+func WithDebugErrors(guard ...gin.HandlerFunc) GinOption {
+	return func(router *gin.Engine) {
+		agg := newErrorAggregator()
+		WithReporter(agg, ReportWithBuffer(0))(router)
+
+		if len(guard) == 0 {
+			guard = []gin.HandlerFunc{MidDebugGuard()}
+		}
+		g := router.Group("/debug/errors", guard...)
+		g.GET("", func(c *gin.Context) {
+			l := agg.list()
+			if c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+				c.JSON(http.StatusOK, l)
+				return
+			}
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			if err := debugErrorsTpl.Execute(c.Writer, l); err != nil {
+				_ = c.Error(err)
+			}
+		})
+		g.DELETE("", func(c *gin.Context) {
+			agg.reset()
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
+
+// HeaderDebugToken 访问调试接口时携带 token 的请求头，见 DebugGuardWithToken
+const HeaderDebugToken = "X-Debug-Token"
+
+// DebugGuardOpt MidDebugGuard 的配置项
+type DebugGuardOpt func(*debugGuardConfig)
+
+type debugGuardConfig struct {
+	token string
+	nets  []*net.IPNet
+}
+
+// DebugGuardWithToken 请求头 X-Debug-Token 与 token 一致时允许访问，经反向代理访问时使用
+func DebugGuardWithToken(token string) DebugGuardOpt {
+	return func(cfg *debugGuardConfig) {
+		cfg.token = token
+	}
+}
+
+// DebugGuardWithAllowIPs TCP 连接的对端地址属于 l 时允许访问，l 中为 IP 或 CIDR，如 127.0.0.1、10.0.0.0/8
+// 不读取 X-Forwarded-For 等请求头；经本机反向代理转发时对端地址总是本机，不应允许 127.0.0.1，改用 DebugGuardWithToken
+func DebugGuardWithAllowIPs(l ...string) DebugGuardOpt {
+	return func(cfg *debugGuardConfig) {
+		for _, v := range l {
+			if !strings.Contains(v, "/") {
+				if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+					v += "/32"
+				} else {
+					v += "/128"
+				}
+			}
+			_, n, err := net.ParseCIDR(v)
+			if err != nil {
+				log.WithError(err).WithField("ip", v).Errorln("invalid debug guard ip")
+				continue
+			}
+			cfg.nets = append(cfg.nets, n)
+		}
+	}
+}
+
+// MidDebugGuard 调试接口的访问控制，默认拒绝所有访问，以 404 输出
+// 只有 token 一致或对端地址在允许列表中的请求可以访问，见 DebugGuardWithToken、DebugGuardWithAllowIPs
+//
+//	guard := gi.MidDebugGuard(gi.DebugGuardWithToken(os.Getenv("DEBUG_TOKEN")))
+//	gi.New(gi.WithPprof(guard), gi.WithDebugErrors(guard))
+func MidDebugGuard(opt ...DebugGuardOpt) gin.HandlerFunc {
+	cfg := &debugGuardConfig{}
+	for _, v := range opt {
+		v(cfg)
+	}
+
+	return func(c *gin.Context) {
+		if cfg.token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader(HeaderDebugToken)), []byte(cfg.token)) == 1 {
+			return
+		}
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			for _, n := range cfg.nets {
+				if n.Contains(ip) {
+					return
+				}
+			}
+		}
+		renderError(c, newCusError(ErrCodeNotFound, nil, "not found", nil))
+	}
+}
+
+var debugErrorsTpl = template.Must(template.New("errors").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>errors</title>
+<style>
+body { font-family: monospace; font-size: 13px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<table>
+<tr><th>count</th><th>kind</th><th>code</th><th>msg</th><th>frame</th><th>route</th><th>first seen</th><th>last seen</th><th>request ids</th><th>context</th></tr>
+{{range .}}
+<tr>
+<td>{{.Count}}</td><td>{{.Kind}}</td><td>{{.Code}}</td><td>{{.Msg}}</td><td>{{.Frame}}</td><td>{{.Route}}</td>
+<td>{{.FirstSeen.Format "2006-01-02 15:04:05"}}</td><td>{{.LastSeen.Format "2006-01-02 15:04:05"}}</td>
+<td>{{range .RequestIds}}{{.}}<br>{{end}}</td>
+<td>{{range $k, $v := .Context}}{{$k}}: {{$v}}<br>{{end}}</td>
+</tr>
+{{else}}
+<tr><td colspan="10">no errors</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,86 @@
+package gi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMidDebugGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name   string
+		opt    []DebugGuardOpt
+		remote string
+		token  string
+		want   int
+	}{
+		{"deny by default", nil, "127.0.0.1:1234", "", http.StatusNotFound},
+		{"token", []DebugGuardOpt{DebugGuardWithToken("secret")}, "192.0.2.1:1234", "secret", http.StatusOK},
+		{"wrong token", []DebugGuardOpt{DebugGuardWithToken("secret")}, "192.0.2.1:1234", "secre", http.StatusNotFound},
+		{"empty token", []DebugGuardOpt{DebugGuardWithToken("")}, "192.0.2.1:1234", "", http.StatusNotFound},
+		{"allowed ip", []DebugGuardOpt{DebugGuardWithAllowIPs("10.0.0.0/8")}, "10.1.2.3:1234", "", http.StatusOK},
+		{"allowed single ip", []DebugGuardOpt{DebugGuardWithAllowIPs("::1")}, "[::1]:1234", "", http.StatusOK},
+		{"ip not allowed", []DebugGuardOpt{DebugGuardWithAllowIPs("10.0.0.0/8")}, "192.0.2.1:1234", "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		r := New(WithDebugErrors(MidDebugGuard(tc.opt...)))
+		req := httptest.NewRequest(http.MethodGet, "/debug/errors?format=json", nil)
+		req.RemoteAddr = tc.remote
+		req.Header.Set("X-Forwarded-For", "127.0.0.1")
+		req.Header.Set("Accept", "application/json")
+		if tc.token != "" {
+			req.Header.Set(HeaderDebugToken, tc.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.want)
+		}
+		if w.Code == http.StatusNotFound && w.Header().Get("Content-Type") != MIMEProblemJSON {
+			t.Errorf("%s: content type = %q, want %q", tc.name, w.Header().Get("Content-Type"), MIMEProblemJSON)
+		}
+	}
+}
+
+func TestReportErrorFingerprint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rep := NewMemReporter()
+	r := New(WithReporter(rep, ReportWithBuffer(0)))
+	r.GET("/a", func(c *gin.Context) { HandleError(c, fmt.Errorf("db down")) })
+	r.GET("/b", func(c *gin.Context) { HandleError(c, fmt.Errorf("timeout")) })
+	r.GET("/c", func(c *gin.Context) { HandleError(c, &driverError{}) })
+
+	for _, path := range []string{"/a", "/b", "/c", "/a"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	l := rep.Reports()
+	if len(l) != 4 {
+		t.Fatalf("reports = %d, want 4", len(l))
+	}
+	for _, v := range l {
+		if len(v.Frames) == 0 {
+			t.Errorf("%s: no frames", v.Request.Route)
+		}
+	}
+	if l[0].Fingerprint != l[3].Fingerprint {
+		t.Errorf("same route and error type: fingerprints differ")
+	}
+	seen := map[string]string{}
+	for _, v := range l[:3] {
+		if route, ok := seen[v.Fingerprint]; ok {
+			t.Errorf("%s and %s share fingerprint %s", route, v.Request.Route, v.Fingerprint)
+		}
+		seen[v.Fingerprint] = v.Request.Route
+	}
+}
+
+type driverError struct{}
+
+func (*driverError) Error() string { return "driver error" }
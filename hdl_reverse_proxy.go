@@ -1,6 +1,7 @@
 package gi
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httputil"
@@ -13,6 +14,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+type proxyCtxKey struct{}
+
 // NewReverseProxyHandler 创建反向代理handler，当代理请求不能正常执行时，返回404，隐藏后端oss详细信息
 func NewReverseProxyHandler(target *url.URL) gin.HandlerFunc {
 	proxy := &httputil.ReverseProxy{
@@ -36,6 +39,10 @@ func NewReverseProxyHandler(target *url.URL) gin.HandlerFunc {
 			return nil
 		},
 		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if c, ok := r.Context().Value(proxyCtxKey{}).(*gin.Context); ok {
+				renderError(c, newCusError(ErrCodeNotFound, err, "not found", nil))
+				return
+			}
 			w.WriteHeader(http.StatusNotFound)
 			_, _ = w.Write([]byte("not found"))
 		},
@@ -45,9 +52,14 @@ func NewReverseProxyHandler(target *url.URL) gin.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				logrus.Errorln("proxy err", err)
-				c.AbortWithStatus(http.StatusServiceUnavailable) // 当作临时不可用
+				if err != http.ErrAbortHandler { // 客户端断开，不必上报
+					reportPanic(c, err, captureFrames(2))
+				}
+				// 当作临时不可用
+				renderError(c, newCusError(ErrCodeServiceUnavailable, nil, "服务暂不可用，请稍后重试", nil))
 			}
 		}()
-		proxy.ServeHTTP(c.Writer, c.Request)
+		// ErrorHandler 中通过 gin.Context 输出错误
+		proxy.ServeHTTP(c.Writer, c.Request.WithContext(context.WithValue(c.Request.Context(), proxyCtxKey{}, c)))
 	}
 }
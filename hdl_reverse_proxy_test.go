@@ -0,0 +1,40 @@
+package gi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReverseProxyErrorRendered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	backend := httptest.NewServer(http.NotFoundHandler())
+	target, _ := url.Parse(backend.URL)
+	backend.Close() // 后端不可用
+
+	r := New()
+	r.GET("/static/*path", NewReverseProxyHandler(target))
+
+	// ResponseRecorder 不支持 CloseNotify，经真实连接访问
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/static/a.js", nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != MIMEProblemJSON {
+		t.Errorf("content type = %q, want %q", ct, MIMEProblemJSON)
+	}
+}
@@ -0,0 +1,131 @@
+package gi
+
+import (
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const localeKey = "gi.locale"
+
+// localeTags 与 supportedLocales 一一对应
+var localeTags = []language.Tag{
+	language.SimplifiedChinese,
+	language.English,
+	language.TraditionalChinese,
+	language.Japanese,
+}
+
+var localeMatcher = language.NewMatcher(localeTags)
+
+// ParseLocale 将 zh-CN、zh-TW、en-US、ja 等语言标签匹配到支持的 Locale
+func ParseLocale(s string) (Locale, bool) {
+	if s == "" {
+		return "", false
+	}
+	tag, err := language.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	return matchLocale(tag)
+}
+
+func matchLocale(tags ...language.Tag) (Locale, bool) {
+	_, idx, conf := localeMatcher.Match(tags...)
+	if conf == language.No {
+		return "", false
+	}
+	return supportedLocales[idx], true
+}
+
+// LocaleOpt MidLocale 的配置项
+type LocaleOpt func(*localeConfig)
+
+type localeConfig struct {
+	queryKey   string
+	cookieName string
+	sessionKey string
+}
+
+// LocaleWithQuery 从 query 参数中取语言，默认为 lang，传空字符串禁用
+func LocaleWithQuery(key string) LocaleOpt {
+	return func(cfg *localeConfig) {
+		cfg.queryKey = key
+	}
+}
+
+// LocaleWithCookie 从 cookie 中取语言，默认为 lang，传空字符串禁用
+func LocaleWithCookie(name string) LocaleOpt {
+	return func(cfg *localeConfig) {
+		cfg.cookieName = name
+	}
+}
+
+// LocaleWithSession 从 session 中取语言，默认不启用，需配合 MidCookieSession 使用
+func LocaleWithSession(key string) LocaleOpt {
+	return func(cfg *localeConfig) {
+		cfg.sessionKey = key
+	}
+}
+
+// MidLocale 为每个请求协商语言，优先级为 query 参数、cookie、session、Accept-Language
+// 都没有时使用默认语言， 见 WithLocale。结果可通过 GetLocale 获取
+func MidLocale(opt ...LocaleOpt) gin.HandlerFunc {
+	cfg := &localeConfig{
+		queryKey:   "lang",
+		cookieName: "lang",
+	}
+	for _, v := range opt {
+		v(cfg)
+	}
+
+	return func(c *gin.Context) {
+		c.Set(localeKey, negotiateLocale(c, cfg))
+	}
+}
+
+func negotiateLocale(c *gin.Context, cfg *localeConfig) Locale {
+	if cfg.queryKey != "" {
+		if l, ok := ParseLocale(c.Query(cfg.queryKey)); ok {
+			return l
+		}
+	}
+
+	if cfg.cookieName != "" {
+		if s, err := c.Cookie(cfg.cookieName); err == nil {
+			if l, ok := ParseLocale(s); ok {
+				return l
+			}
+		}
+	}
+
+	// 未使用 session 中间件时 sessions.Default 会 panic，先检查
+	if _, exists := c.Get(sessions.DefaultKey); exists && cfg.sessionKey != "" {
+		if s, ok := sessions.Default(c).Get(cfg.sessionKey).(string); ok {
+			if l, ok := ParseLocale(s); ok {
+				return l
+			}
+		}
+	}
+
+	if tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language")); err == nil && len(tags) > 0 {
+		if l, ok := matchLocale(tags...); ok {
+			return l
+		}
+	}
+
+	return DefaultLocale()
+}
+
+// GetLocale 当前请求的语言，未使用 MidLocale 时为默认语言
+func GetLocale(c *gin.Context) Locale {
+	if v, ok := c.Get(localeKey); ok {
+		return v.(Locale)
+	}
+	return DefaultLocale()
+}
+
+// SetLocale 设置当前请求的语言，如根据登录用户的偏好设置
+func SetLocale(c *gin.Context, locale Locale) {
+	c.Set(localeKey, locale)
+}
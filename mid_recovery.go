@@ -1,21 +1,21 @@
 package gi
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/http/httputil"
 	"os"
-	"runtime"
+	"strings"
+	"syscall"
 
+	"github.com/cockroachdb/errors"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
 
 var (
-	dunno     = []byte("???")
-	centerDot = []byte("·")
-	dot       = []byte(".")
-	slash     = []byte("/")
+	dunno = []byte("???")
 )
 
 // MidRecovery 防止panic
@@ -27,84 +27,78 @@ type ginRecovery struct {
 }
 
 // Recovery returns a middleware that recovers from any panics and writes a 500 if there was one.
+// panic 转换为 ErrCodePanicErr 的 CusError，与 HandleError 使用相同的格式输出
 func (p *ginRecovery) Recovery(c *gin.Context) {
 	defer func() {
-		if err := recover(); err != nil {
-			stack := p.stack(3)
-			req, _ := httputil.DumpRequest(c.Request, false)
-			log.WithField("err", fmt.Sprintf("%+v", err)).
-				WithField("req", string(req)).
-				WithField("stack", string(stack)).
-				WithField("ip", c.ClientIP()).
-				Errorln("panic recovered", err)
-			if gin.Mode() == gin.DebugMode {
-				fmt.Println("panic recovered", err)
-				fmt.Println(string(stack))
-			}
-			c.AbortWithStatus(500)
+		rec := recover()
+		if rec == nil {
+			return
 		}
-	}()
-	c.Next()
-}
 
-// stack returns a nicely formated stack frame, skipping skip frames
-func (p *ginRecovery) stack(skip int) []byte {
-	buf := new(bytes.Buffer) // the returned data
-	// As we loop, we open files and read them. These variables record the currently
-	// loaded file.
-	var lines [][]byte
-	var lastFile string
-	for i := skip; ; i++ {
-		// Skip the expected number of frames
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
-			break
+		// net/http 约定的中止信号，交还给 http.Server 处理
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+
+		err := panicErr(rec)
+
+		// 客户端已断开，无法再写入，也不是服务端的问题
+		if isClientAbort(err) {
+			log.WithError(err).
+				WithField("path", c.Request.URL.Path).
+				WithField("requestId", GetRequestId(c)).
+				Debugln("client aborted")
+			c.Abort()
+			return
 		}
-		// Print this much at least.  If we can't find the source, it won't show.
-		fmt.Fprintf(buf, "%s:%d (0x%x)\n", file, line, pc)
-		if file != lastFile {
-			data, err := os.ReadFile(file)
-			if err != nil {
-				continue
-			}
-			lines = bytes.Split(data, []byte{'\n'})
-			lastFile = file
+
+		// 跳过当前函数及 runtime.gopanic，从 panic 处开始
+		frames := captureFrames(2)
+		framesJson, _ := json.Marshal(frames)
+		req, _ := httputil.DumpRequest(c.Request, false)
+		log.WithField("err", fmt.Sprintf("%+v", rec)).
+			WithField("req", string(req)).
+			WithField("frames", string(framesJson)).
+			WithField("fingerprint", panicFingerprint(rec, frames)).
+			WithField("ip", c.ClientIP()).
+			WithField("requestId", GetRequestId(c)).
+			Errorln("panic recovered", rec)
+		if gin.Mode() == gin.DebugMode {
+			fmt.Println("panic recovered", rec)
+			fmt.Println(string(framesText(frames)))
 		}
-		fmt.Fprintf(buf, "\t%s: %s\n", p.function(pc), p.source(lines, line))
-	}
-	return buf.Bytes()
+		reportPanic(c, rec, frames)
+
+		// 已开始输出响应时不能再写入
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+
+		ce, _ := IsCusError(WrapPanicCusError(err, "服务错误，请稍后重试"))
+		renderError(c, ce)
+	}()
+	c.Next()
 }
 
-// source returns a space-trimmed slice of the n'th line.
-func (p *ginRecovery) source(lines [][]byte, n int) []byte {
-	n-- // in stack trace, lines are 1-indexed but our array is 0-indexed
-	if n < 0 || n >= len(lines) {
-		return dunno
+// panicErr 将 panic 的值转换为 error
+func panicErr(rec any) error {
+	if err, ok := rec.(error); ok {
+		return err
 	}
-	return bytes.TrimSpace(lines[n])
+	return errors.Newf("%v", rec)
 }
 
-// function returns, if possible, the name of the function containing the PC.
-func (p *ginRecovery) function(pc uintptr) []byte {
-	fn := runtime.FuncForPC(pc)
-	if fn == nil {
-		return dunno
-	}
-	name := []byte(fn.Name())
-	// The name includes the path name to the package, which is unnecessary
-	// since the file name is already included.  Plus, it has center dots.
-	// That is, we see
-	//	runtime/debug.*T·ptrmethod
-	// and want
-	//	*T.ptrmethod
-	// Also the package path might contains dot (e.g. code.google.com/...),
-	// so first eliminate the path prefix
-	if lastslash := bytes.LastIndex(name, slash); lastslash >= 0 {
-		name = name[lastslash+1:]
+// isClientAbort 客户端断开连接导致的写入失败
+func isClientAbort(err error) bool {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
 	}
-	if period := bytes.Index(name, dot); period >= 0 {
-		name = name[period+1:]
+
+	var se *os.SyscallError
+	if errors.As(err, &se) {
+		msg := strings.ToLower(se.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
 	}
-	name = bytes.Replace(name, centerDot, dot, -1)
-	return name
+	return false
 }
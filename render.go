@@ -0,0 +1,56 @@
+package gi
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// renderFormat 一种可按 Accept 协商的响应格式
+type renderFormat struct {
+	mime   string
+	render func(v any) render.Render
+}
+
+// bodyFormats json 以外可协商的响应格式，与 binding.Default 支持的请求体格式对应
+// msgpack 在 render_msgpack.go 中加入，使用 nomsgpack 构建时不可用
+var bodyFormats = []renderFormat{
+	{binding.MIMEYAML2, func(v any) render.Render { return render.YAML{Data: v} }},
+	{binding.MIMEYAML, func(v any) render.Render { return render.YAML{Data: v} }},
+	{binding.MIMETOML, func(v any) render.Render { return render.TOML{Data: v} }},
+}
+
+// Render 按请求的 Accept 选择格式输出 v，支持 json、yaml、toml、msgpack，v 实现了 proto.Message 时还支持 protobuf
+// 未指定 Accept 或没有可接受的格式时输出 json
+func Render(c *gin.Context, code int, v any) {
+	offered := make([]string, 0, len(bodyFormats)+2)
+	offered = append(offered, gin.MIMEJSON)
+	for _, f := range bodyFormats {
+		offered = append(offered, f.mime)
+	}
+	if _, ok := v.(proto.Message); ok {
+		offered = append(offered, binding.MIMEPROTOBUF)
+	}
+
+	format := c.NegotiateFormat(offered...)
+	if format == binding.MIMEPROTOBUF {
+		c.ProtoBuf(code, v)
+		return
+	}
+	if f, ok := findFormat(format); ok {
+		c.Render(code, f.render(v))
+		return
+	}
+	c.JSON(code, v)
+}
+
+// findFormat 按 NegotiateFormat 的结果查找 bodyFormats
+func findFormat(mime string) (renderFormat, bool) {
+	for _, f := range bodyFormats {
+		if f.mime == mime {
+			return f, true
+		}
+	}
+	return renderFormat{}, false
+}
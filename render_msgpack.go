@@ -0,0 +1,16 @@
+//go:build !nomsgpack
+
+package gi
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+func init() {
+	msgpack := func(v any) render.Render { return render.MsgPack{Data: v} }
+	bodyFormats = append(bodyFormats,
+		renderFormat{binding.MIMEMSGPACK2, msgpack},
+		renderFormat{binding.MIMEMSGPACK, msgpack},
+	)
+}
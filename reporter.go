@@ -0,0 +1,452 @@
+package gi
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/quexer/utee"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ReportKindError = "error" // 5xx 的 CusError
+	ReportKindPanic = "panic" // recover 的 panic
+)
+
+// Reporter 错误上报， 接收所有 5xx 的 CusError 及 recover 的 panic
+// 通过 WithReporter 注册到 gin.Engine
+type Reporter interface {
+	Report(r *Report)
+}
+
+// Report 一次错误上报
+type Report struct {
+	Kind        string        // ReportKindError 或 ReportKindPanic
+	Time        time.Time     // 发生时间
+	Code        ErrCode       // 错误码
+	Msg         string        // 提示信息，panic 时为原始错误描述
+	Err         error         // 原始错误，可能含敏感信息，见 RedactedErr
+	Panic       any           // panic 值，仅 Kind 为 ReportKindPanic 时有值
+	Stack       []byte        // 文本格式的调用栈，仅 Kind 为 ReportKindPanic 时有值
+	Frames      []StackFrame  // 结构化的调用栈
+	Fingerprint string        // 同一出错位置的稳定标识，用于聚合
+	Context     utee.J        // CusError 的 context，可能含敏感信息，见 RedactedContext
+	Request     ReportRequest // 请求信息
+}
+
+// RedactedErr 脱敏后的错误描述，敏感内容显示为 ‹×›，发送到外部服务时使用
+// 运行时错误（如数组越界）不含业务数据，原样保留
+func (p *Report) RedactedErr() string {
+	if p.Err == nil {
+		return ""
+	}
+	var re runtime.Error
+	if errors.As(p.Err, &re) {
+		return re.Error()
+	}
+	return redactedErr(p.Err)
+}
+
+// RedactedContext 脱敏后的 context，只有 Safe、Public 标记的值保留原值
+func (p *Report) RedactedContext() map[string]any {
+	m := make(map[string]any, len(p.Context))
+	for k, v := range p.Context {
+		m[k] = redactedValue(v)
+	}
+	return m
+}
+
+// ReportRequest 上报时附带的请求信息
+type ReportRequest struct {
+	Method    string
+	Route     string // 路由模板，如 /users/:id
+	Path      string
+	Query     string // 已脱敏
+	RequestId string
+	UserId    string
+	IP        string
+	Headers   map[string]string // 已脱敏
+}
+
+// ReportOpt Reporter 的配置项
+type ReportOpt func(*reportConfig)
+
+type reportConfig struct {
+	sampleRate float64                     // 采样率， 0~1
+	bufferSize int                         // 异步缓冲大小， 0 为同步上报
+	userId     func(c *gin.Context) string // 获取用户id
+	sensitive  map[string]bool             // 需要脱敏的 header， canonical 格式
+	params     map[string]bool             // 需要脱敏的 query 参数，小写
+}
+
+// ReportWithSampleRate 采样率，取值 0~1， 默认为 1，全部上报
+func ReportWithSampleRate(rate float64) ReportOpt {
+	return func(cfg *reportConfig) {
+		cfg.sampleRate = rate
+	}
+}
+
+// ReportWithBuffer 异步上报的缓冲大小，默认为 1024；缓冲满时丢弃
+// size 为 0 时同步上报，适合测试
+func ReportWithBuffer(size int) ReportOpt {
+	return func(cfg *reportConfig) {
+		cfg.bufferSize = size
+	}
+}
+
+// ReportWithUserId 提供获取当前用户id的函数
+func ReportWithUserId(fn func(c *gin.Context) string) ReportOpt {
+	return func(cfg *reportConfig) {
+		cfg.userId = fn
+	}
+}
+
+// ReportWithSensitiveHeader 追加需要脱敏的 header
+// 默认脱敏 Authorization、Cookie 及 MidCORS 中允许的各类 token
+func ReportWithSensitiveHeader(names ...string) ReportOpt {
+	return func(cfg *reportConfig) {
+		for _, v := range names {
+			cfg.sensitive[http.CanonicalHeaderKey(v)] = true
+		}
+	}
+}
+
+// ReportWithSensitiveParam 追加需要脱敏的 query 参数，不区分大小写
+// 默认脱敏 token、password、secret、签名等常见参数
+func ReportWithSensitiveParam(names ...string) ReportOpt {
+	return func(cfg *reportConfig) {
+		for _, v := range names {
+			cfg.params[strings.ToLower(v)] = true
+		}
+	}
+}
+
+var defaultSensitiveHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+	"Token", "AccessToken", "X-CSRF-Token", "X-Api-Key", "wos-auth-session", "Fake-Id",
+}
+
+var defaultSensitiveParams = []string{
+	"token", "access_token", "accessToken", "refresh_token", "id_token", "api_key", "apikey",
+	"password", "passwd", "pwd", "secret", "client_secret", "sign", "signature", "code", "ticket",
+}
+
+// WithReporter 注册 Reporter，可注册多个
+// 异步上报时在后台 goroutine 中送出，服务退出前应调用 CloseReporters，以免丢失缓冲中的上报
+func WithReporter(rep Reporter, opt ...ReportOpt) GinOption {
+	cfg := &reportConfig{
+		sampleRate: 1,
+		bufferSize: 1024,
+		sensitive:  map[string]bool{},
+		params:     map[string]bool{},
+	}
+	for _, v := range defaultSensitiveHeaders {
+		cfg.sensitive[http.CanonicalHeaderKey(v)] = true
+	}
+	for _, v := range defaultSensitiveParams {
+		cfg.params[strings.ToLower(v)] = true
+	}
+	for _, v := range opt {
+		v(cfg)
+	}
+
+	runner := &reportRunner{rep: rep, cfg: cfg}
+	if cfg.bufferSize > 0 {
+		runner.ch = make(chan reportItem, cfg.bufferSize)
+		runners.add(runner)
+		go runner.loop()
+	}
+
+	return with(func(c *gin.Context) {
+		var l []*reportRunner
+		if v, ok := c.Get(reportersKey); ok {
+			l = append(l, v.([]*reportRunner)...)
+		}
+		c.Set(reportersKey, append(l, runner))
+	})
+}
+
+const reportersKey = "gi.reporters"
+
+type reportRunner struct {
+	rep    Reporter
+	cfg    *reportConfig
+	ch     chan reportItem
+	mu     sync.RWMutex // 保护 closed 及向 ch 发送
+	closed bool
+	done   chan struct{} // loop 退出时关闭
+}
+
+// reportItem 异步缓冲中的一项，flushed 不为 nil 时为 FlushReporters 的标记，此前的上报都已送出
+type reportItem struct {
+	r       *Report
+	flushed chan struct{}
+}
+
+func (p *reportRunner) loop() {
+	defer close(p.done)
+	for v := range p.ch {
+		if v.flushed != nil {
+			close(v.flushed)
+			continue
+		}
+		p.deliver(v.r)
+	}
+}
+
+// flush 在缓冲末尾放入标记并等待 loop 处理到该标记
+func (p *reportRunner) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return nil
+	}
+	select {
+	case p.ch <- reportItem{flushed: flushed}:
+		p.mu.RUnlock()
+	case <-ctx.Done():
+		p.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close 不再接收新的上报，缓冲中的上报仍会送出
+func (p *reportRunner) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.ch)
+	}
+}
+
+// asyncRunners 异步上报的 reportRunner，供 FlushReporters、CloseReporters 使用
+type asyncRunners struct {
+	sync.Mutex
+	l []*reportRunner
+}
+
+var runners asyncRunners
+
+func (p *asyncRunners) add(r *reportRunner) {
+	r.done = make(chan struct{})
+	p.Lock()
+	defer p.Unlock()
+	p.l = append(p.l, r)
+}
+
+func (p *asyncRunners) list() []*reportRunner {
+	p.Lock()
+	defer p.Unlock()
+	return append([]*reportRunner(nil), p.l...)
+}
+
+// FlushReporters 等待异步缓冲中的上报全部送出，ctx 结束时返回 ctx.Err()
+func FlushReporters(ctx context.Context) error {
+	for _, r := range runners.list() {
+		if err := r.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseReporters 停止全部异步上报：不再接收新的上报，等待缓冲中的上报送出后结束后台 goroutine
+// 通常在服务退出时调用，ctx 结束时返回 ctx.Err()，未送出的上报丢弃
+func CloseReporters(ctx context.Context) error {
+	runners.Lock()
+	l := runners.l
+	runners.l = nil
+	runners.Unlock()
+
+	for _, r := range l {
+		r.close()
+	}
+	for _, r := range l {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// deliver reporter 出错时不能影响请求处理
+func (p *reportRunner) deliver(r *Report) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.WithField("err", err).Errorln("reporter panic")
+		}
+	}()
+	p.rep.Report(r)
+}
+
+func (p *reportRunner) submit(c *gin.Context, r Report) {
+	if p.cfg.sampleRate < 1 && rand.Float64() >= p.cfg.sampleRate {
+		return
+	}
+
+	// 请求信息必须在当前 goroutine 中取出，gin.Context 会被复用
+	r.Request = p.request(c)
+
+	if p.ch == nil {
+		p.deliver(&r)
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		log.WithField("requestId", r.Request.RequestId).Warnln("reporter closed, dropped")
+		return
+	}
+
+	select {
+	case p.ch <- reportItem{r: &r}:
+	default:
+		log.WithField("requestId", r.Request.RequestId).Warnln("report buffer full, dropped")
+	}
+}
+
+func (p *reportRunner) request(c *gin.Context) ReportRequest {
+	req := ReportRequest{
+		Method:    c.Request.Method,
+		Route:     c.FullPath(),
+		Path:      c.Request.URL.Path,
+		Query:     p.query(c.Request.URL.RawQuery),
+		RequestId: GetRequestId(c),
+		IP:        c.ClientIP(),
+		Headers:   map[string]string{},
+	}
+	if p.cfg.userId != nil {
+		req.UserId = p.cfg.userId(c)
+	}
+
+	for k, v := range c.Request.Header {
+		if p.cfg.sensitive[http.CanonicalHeaderKey(k)] {
+			req.Headers[k] = "[Filtered]"
+			continue
+		}
+		req.Headers[k] = strings.Join(v, ", ")
+	}
+	return req
+}
+
+// query 脱敏 query string 中的敏感参数，其它参数及顺序保持不变
+func (p *reportRunner) query(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	pairs := strings.Split(raw, "&")
+	for i, v := range pairs {
+		k, _, _ := strings.Cut(v, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if p.cfg.params[strings.ToLower(name)] {
+			pairs[i] = k + "=[Filtered]"
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func runReporters(c *gin.Context, r Report) {
+	v, ok := c.Get(reportersKey)
+	if !ok {
+		return
+	}
+	for _, runner := range v.([]*reportRunner) {
+		runner.submit(c, r)
+	}
+}
+
+// reportError 上报 5xx 错误
+func reportError(c *gin.Context, err error, ce *CusError) {
+	if ce.Code().Status() < http.StatusInternalServerError {
+		return
+	}
+
+	frames := errFrames(err)
+	if frames == nil {
+		// 错误链中没有调用栈（如 fmt.Errorf、驱动返回的错误）时取 HandleError 调用方的调用栈
+		frames = captureFrames(2)
+	}
+	runReporters(c, Report{
+		Kind:    ReportKindError,
+		Time:    time.Now(),
+		Code:    ce.Code(),
+		Msg:     ce.Msg(),
+		Err:     err,
+		Context: ce.Context(),
+		Frames:  frames,
+		// 消息中的参数已脱敏为 ‹×›，可作为消息模板；未归类的错误消息相同，以最内层错误的类型及路由区分
+		Fingerprint: fingerprint(frames, ce.Code(), ce.Msg(), fmt.Sprintf("%T", errors.UnwrapAll(err)), c.FullPath()),
+	})
+}
+
+// reportPanic 上报 panic
+func reportPanic(c *gin.Context, rec any, frames []StackFrame) {
+	err := panicErr(rec)
+	runReporters(c, Report{
+		Kind:        ReportKindPanic,
+		Time:        time.Now(),
+		Code:        ErrCodePanicErr,
+		Msg:         err.Error(),
+		Err:         err,
+		Panic:       rec,
+		Stack:       framesText(frames),
+		Frames:      frames,
+		Fingerprint: panicFingerprint(rec, frames),
+	})
+}
+
+// MemReporter 内存 Reporter，用于测试
+// 配合 ReportWithBuffer(0) 同步上报，请求结束后即可断言
+type MemReporter struct {
+	mu      sync.Mutex
+	reports []*Report
+}
+
+// NewMemReporter 创建 MemReporter
+func NewMemReporter() *MemReporter {
+	return &MemReporter{}
+}
+
+func (p *MemReporter) Report(r *Report) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, r)
+}
+
+// Reports 已收到的全部上报
+func (p *MemReporter) Reports() []*Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Report(nil), p.reports...)
+}
+
+// Reset 清空已收到的上报
+func (p *MemReporter) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = nil
+}
@@ -0,0 +1,244 @@
+package gi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SentryOpt Sentry Reporter 的配置项
+type SentryOpt func(*sentryReporter)
+
+// SentryWithEnvironment 环境，如 production、staging
+func SentryWithEnvironment(env string) SentryOpt {
+	return func(p *sentryReporter) {
+		p.environment = env
+	}
+}
+
+// SentryWithRelease 版本号
+func SentryWithRelease(release string) SentryOpt {
+	return func(p *sentryReporter) {
+		p.release = release
+	}
+}
+
+// SentryWithHttpClient 自定义 http.Client， 默认超时 5 秒
+func SentryWithHttpClient(client *http.Client) SentryOpt {
+	return func(p *sentryReporter) {
+		p.client = client
+	}
+}
+
+type sentryReporter struct {
+	dsn         string
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+// NewSentryReporter 以 envelope 格式通过 http 上报到 Sentry，不依赖 sentry sdk
+// dsn 格式为 {scheme}://{key}@{host}/{path}{project_id}
+func NewSentryReporter(dsn string, opt ...SentryOpt) (Reporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse sentry dsn")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.Newf("sentry dsn missing public key: %s", dsn)
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	project := path[idx+1:]
+	if project == "" {
+		return nil, errors.Newf("sentry dsn missing project id: %s", dsn)
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=gi/1.0, sentry_key=%s", u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	host, _ := os.Hostname()
+	p := &sentryReporter{
+		dsn:        dsn,
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:idx], project),
+		auth:       auth,
+		serverName: host,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, v := range opt {
+		v(p)
+	}
+	return p, nil
+}
+
+func (p *sentryReporter) Report(r *Report) {
+	body, err := p.envelope(r)
+	if err != nil {
+		log.WithError(err).Errorln("build sentry envelope")
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		log.WithError(err).Errorln("new sentry request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", p.auth)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		log.WithError(err).Warnln("send sentry envelope")
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		log.WithField("status", resp.StatusCode).Warnln("sentry rejected envelope")
+	}
+}
+
+// envelope 依次为 envelope header、item header、event，以换行分隔
+func (p *sentryReporter) envelope(r *Report) ([]byte, error) {
+	eventId := newEventId()
+	event, err := json.Marshal(p.event(eventId, r))
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json.Marshal(map[string]any{
+		"event_id": eventId,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      p.dsn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	itemHeader, err := json.Marshal(map[string]any{
+		"type":   "event",
+		"length": len(event),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	for _, v := range [][]byte{header, itemHeader, event} {
+		buf.Write(v)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *sentryReporter) event(eventId string, r *Report) map[string]any {
+	level := "error"
+	errType := fmt.Sprintf("%T", errors.UnwrapAll(r.Err))
+	if r.Kind == ReportKindPanic {
+		level = "fatal"
+		errType = fmt.Sprintf("%T", r.Panic)
+	}
+
+	// 只发送脱敏后的内容，Msg 对 CusError 是客户端可见的提示，panic 时为原始描述
+	msg := r.Msg
+	if r.Kind == ReportKindPanic {
+		msg = r.RedactedErr()
+	}
+
+	extra := map[string]any{}
+	for k, v := range r.RedactedContext() {
+		extra[k] = fmt.Sprint(v)
+	}
+
+	exception := map[string]any{
+		"type":  errType,
+		"value": r.RedactedErr(),
+	}
+	if len(r.Frames) > 0 {
+		exception["stacktrace"] = map[string]any{"frames": sentryFrames(r.Frames)}
+	}
+
+	event := map[string]any{
+		"event_id":    eventId,
+		"timestamp":   r.Time.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       level,
+		"logger":      "gi",
+		"server_name": p.serverName,
+		"message":     msg,
+		"exception": map[string]any{
+			"values": []map[string]any{exception},
+		},
+		"request": map[string]any{
+			"method":       r.Request.Method,
+			"url":          r.Request.Path,
+			"query_string": r.Request.Query,
+			"headers":      r.Request.Headers,
+		},
+		"user": map[string]any{
+			"id":         r.Request.UserId,
+			"ip_address": r.Request.IP,
+		},
+		"tags": map[string]string{
+			"kind":      r.Kind,
+			"code":      r.Code.Error(),
+			"route":     r.Request.Route,
+			"requestId": r.Request.RequestId,
+		},
+		"extra": extra,
+	}
+	if r.Fingerprint != "" {
+		event["fingerprint"] = []string{r.Fingerprint}
+	}
+	if p.environment != "" {
+		event["environment"] = p.environment
+	}
+	if p.release != "" {
+		event["release"] = p.release
+	}
+	return event
+}
+
+// sentryFrames sentry 要求调用栈由外到内排列，与 runtime 的顺序相反
+func sentryFrames(frames []StackFrame) []map[string]any {
+	l := make([]map[string]any, 0, len(frames))
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		l = append(l, map[string]any{
+			"function":     f.Function,
+			"abs_path":     f.File,
+			"filename":     filepath.Base(f.File),
+			"lineno":       f.Line,
+			"in_app":       f.InApp,
+			"pre_context":  f.PreContext,
+			"context_line": f.ContextLine,
+			"post_context": f.PostContext,
+		})
+	}
+	return l
+}
+
+func newEventId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
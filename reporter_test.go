@@ -0,0 +1,143 @@
+package gi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// blockReporter 收到第一个上报后等待 release 关闭
+type blockReporter struct {
+	MemReporter
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockReporter() *blockReporter {
+	return &blockReporter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (p *blockReporter) Report(r *Report) {
+	p.once.Do(func() { close(p.started) })
+	<-p.release
+	p.MemReporter.Report(r)
+}
+
+type panicReporter struct{}
+
+func (panicReporter) Report(*Report) { panic("boom") }
+
+// reportRouter /err 返回 500，/ok 返回 200
+func reportRouter(opt ...GinOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := New(opt...)
+	r.GET("/err", func(c *gin.Context) { HandleError(c, errors.New("db down")) })
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func serve(r *gin.Engine, target string, header ...string) int {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestReporterSampling(t *testing.T) {
+	cases := []struct {
+		opt  []ReportOpt
+		want int
+	}{
+		{[]ReportOpt{ReportWithBuffer(0)}, 3},
+		{[]ReportOpt{ReportWithBuffer(0), ReportWithSampleRate(0)}, 0},
+		{[]ReportOpt{ReportWithBuffer(0), ReportWithSampleRate(1)}, 3},
+	}
+	for i, tc := range cases {
+		rep := NewMemReporter()
+		r := reportRouter(WithReporter(rep, tc.opt...))
+		for range 3 {
+			serve(r, "/err")
+		}
+		serve(r, "/ok")
+		if got := len(rep.Reports()); got != tc.want {
+			t.Errorf("case %d: reports = %d, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestReporterRedaction(t *testing.T) {
+	rep := NewMemReporter()
+	r := reportRouter(WithReporter(rep, ReportWithBuffer(0), ReportWithSensitiveHeader("X-Secret"), ReportWithSensitiveParam("card")))
+	serve(r, "/err?page=1&Token=abc&card=4111&a%5Bb%5D=1",
+		"Authorization", "Bearer x", "X-Secret", "s", "X-Trace", "t")
+
+	l := rep.Reports()
+	if len(l) != 1 {
+		t.Fatalf("reports = %d, want 1", len(l))
+	}
+	req := l[0].Request
+	if want := "page=1&Token=[Filtered]&card=[Filtered]&a%5Bb%5D=1"; req.Query != want {
+		t.Errorf("query = %q, want %q", req.Query, want)
+	}
+	want := map[string]string{"Authorization": "[Filtered]", "X-Secret": "[Filtered]", "X-Trace": "t"}
+	for k, v := range want {
+		if req.Headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, req.Headers[k], v)
+		}
+	}
+	if req.Route != "/err" {
+		t.Errorf("route = %q, want /err", req.Route)
+	}
+}
+
+func TestReporterPanicIgnored(t *testing.T) {
+	r := reportRouter(WithReporter(panicReporter{}, ReportWithBuffer(0)))
+	if code := serve(r, "/err"); code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", code)
+	}
+}
+
+func TestReporterFlushAndClose(t *testing.T) {
+	rep := newBlockReporter()
+	r := reportRouter(WithReporter(rep, ReportWithBuffer(1)))
+
+	serve(r, "/err")
+	<-rep.started // 第一个上报已被取出，阻塞在 Report 中
+	serve(r, "/err") // 进入缓冲
+	serve(r, "/err") // 缓冲已满，丢弃
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := FlushReporters(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FlushReporters while blocked = %v, want deadline exceeded", err)
+	}
+
+	close(rep.release)
+	if err := FlushReporters(context.Background()); err != nil {
+		t.Fatalf("FlushReporters: %v", err)
+	}
+	if got := len(rep.Reports()); got != 2 {
+		t.Errorf("reports after flush = %d, want 2", got)
+	}
+
+	if err := CloseReporters(context.Background()); err != nil {
+		t.Fatalf("CloseReporters: %v", err)
+	}
+	serve(r, "/err") // 关闭后丢弃，不能 panic
+	if err := FlushReporters(context.Background()); err != nil {
+		t.Errorf("FlushReporters after close: %v", err)
+	}
+	if got := len(rep.Reports()); got != 2 {
+		t.Errorf("reports after close = %d, want 2", got)
+	}
+}
@@ -0,0 +1,211 @@
+package gi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// sourceContext 每一帧前后各附带的源码行数
+const sourceContext = 3
+
+// StackFrame 调用栈中的一帧
+type StackFrame struct {
+	Function    string   `json:"function"`              // 完整函数名，含包路径
+	File        string   `json:"file"`                  // 源文件绝对路径
+	Line        int      `json:"line"`                  // 行号，从 1 开始
+	PreContext  []string `json:"preContext,omitempty"`  // 当前行之前的源码
+	ContextLine string   `json:"contextLine,omitempty"` // 当前行源码
+	PostContext []string `json:"postContext,omitempty"` // 当前行之后的源码
+	InApp       bool     `json:"inApp"`                 // 是否为业务代码，标准库及第三方库为 false
+}
+
+// String 与原 stack 文本格式一致：函数短名及当前行源码
+func (p StackFrame) String() string {
+	return fmt.Sprintf("%s:%d\n\t%s: %s", p.File, p.Line, shortFuncName(p.Function), p.ContextLine)
+}
+
+// captureFrames 取调用栈，skip 为 0 时从 captureFrames 的调用方开始
+func captureFrames(skip int) []StackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var l []StackFrame
+	for {
+		f, more := frames.Next()
+		sf := StackFrame{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+			InApp:    isInApp(f.Function, f.File),
+		}
+		sf.PreContext, sf.ContextLine, sf.PostContext = sources.context(f.File, f.Line)
+		l = append(l, sf)
+
+		if !more {
+			break
+		}
+	}
+	return l
+}
+
+// errFrames 取 cockroachdb/errors 在错误链中记录的调用栈，没有时返回 nil
+func errFrames(err error) []StackFrame {
+	st := errors.GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+
+	// sentry 格式由外到内排列，转换为与 runtime 一致的由内到外
+	l := make([]StackFrame, 0, len(st.Frames))
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		sf := StackFrame{
+			Function: f.Function,
+			File:     f.AbsPath,
+			Line:     f.Lineno,
+		}
+		if f.Module != "" {
+			sf.Function = f.Module + "." + f.Function
+		}
+		sf.InApp = isInApp(sf.Function, sf.File)
+		sf.PreContext, sf.ContextLine, sf.PostContext = sources.context(sf.File, sf.Line)
+		l = append(l, sf)
+	}
+	return l
+}
+
+// framesText 文本格式的调用栈，用于调试模式下直接打印
+func framesText(frames []StackFrame) []byte {
+	buf := new(bytes.Buffer)
+	for _, v := range frames {
+		buf.WriteString(v.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// fingerprint 同一出错位置的稳定标识，用于日志聚合
+// 由 parts（如 panic 值的类型、错误码、消息模板、路由）及第一个业务帧的函数名、行号计算， 不含易变的内容
+func fingerprint(frames []StackFrame, parts ...any) string {
+	h := sha1.New()
+	for _, v := range parts {
+		fmt.Fprintf(h, "%v|", v)
+	}
+	if f, ok := topInAppFrame(frames); ok {
+		fmt.Fprintf(h, "%s:%d", f.Function, f.Line)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// panicFingerprint panic 的标识，由 panic 值的类型及出错位置计算
+func panicFingerprint(rec any, frames []StackFrame) string {
+	return fingerprint(frames, fmt.Sprintf("%T", rec))
+}
+
+// topInAppFrame 第一个业务帧，没有业务帧时取第一个非 runtime 帧
+func topInAppFrame(frames []StackFrame) (StackFrame, bool) {
+	for _, v := range frames {
+		if v.InApp {
+			return v, true
+		}
+	}
+	for _, v := range frames {
+		if !strings.HasPrefix(v.Function, "runtime.") {
+			return v, true
+		}
+	}
+	return StackFrame{}, false
+}
+
+// isInApp 标准库、go module 缓存及 vendor 中的代码视为非业务代码
+func isInApp(function, file string) bool {
+	if strings.Contains(file, "/pkg/mod/") || strings.Contains(file, "/vendor/") {
+		return false
+	}
+
+	// 标准库包路径的第一段不含 "."，如 net/http、runtime；main 包除外
+	pkg := function
+	if i := strings.Index(pkg, "/"); i >= 0 {
+		pkg = pkg[:i]
+	} else if i := strings.Index(pkg, "."); i >= 0 {
+		pkg = pkg[:i]
+	}
+	return pkg == "main" || strings.Contains(pkg, ".")
+}
+
+// shortFuncName 去掉包路径，如 github.com/x/y.(*T).m 输出为 (*T).m
+func shortFuncName(name string) string {
+	if name == "" {
+		return string(dunno)
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ReplaceAll(name, "·", ".")
+}
+
+// sourceCacheSize 缓存的源文件个数上限
+const sourceCacheSize = 128
+
+// sourceCache 源文件缓存，避免 panic 频发时反复读盘
+// 读取失败的文件同样缓存，结果为 nil
+type sourceCache struct {
+	sync.RWMutex
+	files map[string][][]byte
+}
+
+var sources = &sourceCache{files: map[string][][]byte{}}
+
+func (p *sourceCache) lines(file string) [][]byte {
+	p.RLock()
+	l, ok := p.files[file]
+	p.RUnlock()
+	if ok {
+		return l
+	}
+
+	if data, err := os.ReadFile(file); err == nil {
+		l = bytes.Split(data, []byte{'\n'})
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if len(p.files) >= sourceCacheSize {
+		// 缓存满时随机淘汰一个
+		for k := range p.files {
+			delete(p.files, k)
+			break
+		}
+	}
+	p.files[file] = l
+	return l
+}
+
+// context 第 line 行及前后各 sourceContext 行源码
+func (p *sourceCache) context(file string, line int) (pre []string, cur string, post []string) {
+	lines := p.lines(file)
+	n := line - 1 // in stack trace, lines are 1-indexed but our array is 0-indexed
+	if n < 0 || n >= len(lines) {
+		return nil, string(dunno), nil
+	}
+
+	for i := max(0, n-sourceContext); i < n; i++ {
+		pre = append(pre, string(bytes.TrimRight(lines[i], "\r")))
+	}
+	for i := n + 1; i < min(len(lines), n+1+sourceContext); i++ {
+		post = append(post, string(bytes.TrimRight(lines[i], "\r")))
+	}
+	return pre, string(bytes.TrimSpace(lines[n])), post
+}
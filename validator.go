@@ -3,152 +3,427 @@ package gi
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ja"
 	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant"
 	unit "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
 	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	zhTwTranslations "github.com/go-playground/validator/v10/translations/zh_tw"
 )
 
 const (
-	ZH Locale = "zh"
-	EN Locale = "en"
+	ZH     Locale = "zh"      // 简体中文
+	EN     Locale = "en"      // 英文
+	ZHHant Locale = "zh_Hant" // 繁体中文
+	JA     Locale = "ja"      // 日文
 )
 
 type Locale string
 
+// supportedLocales 已注册翻译的全部语言
+var supportedLocales = []Locale{ZH, EN, ZHHant, JA}
+
 const (
 	requiredTrimTag = "required_trim"
 	onlyTrimTag     = "trim"
 )
 
+var requiredTrimMsgs = map[Locale]string{
+	ZH:     "{0}为必填字段",
+	EN:     "{0} is a required field",
+	ZHHant: "{0}為必填欄位",
+	JA:     "{0}は必須フィールドです",
+}
+
 var (
-	trans unit.Translator
+	uni           atomic.Pointer[unit.UniversalTranslator]
+	defaultLocale atomic.Value // Locale
 )
 
-func initTrans(locale Locale) error {
+func init() {
+	defaultLocale.Store(ZH)
+}
+
+// SetDefaultLocale 设置默认语言，请求未协商出语言时使用，默认为 ZH
+func SetDefaultLocale(locale Locale) {
+	defaultLocale.Store(locale)
+}
+
+// DefaultLocale 默认语言
+func DefaultLocale() Locale {
+	return defaultLocale.Load().(Locale)
+}
+
+// initTrans 为全部支持的语言注册翻译
+func initTrans() error {
 	v, ok := binding.Validator.Engine().(*validator.Validate)
 	if !ok {
 		return nil
 	}
 
-	if err := v.RegisterValidation(requiredTrimTag, requiredTrim); err != nil {
-		return err
+	enT := en.New()
+	u := unit.New(enT, zh.New(), enT, zh_Hant.New(), ja.New())
+
+	for _, locale := range supportedLocales {
+		trans, ok := u.GetTranslator(string(locale))
+		if !ok {
+			return errors.Errorf("uni.GetTranslator(%s) failed", locale)
+		}
+
+		var err error
+		// 注册翻译器
+		switch locale {
+		case ZH:
+			err = zhTranslations.RegisterDefaultTranslations(v, trans)
+		case ZHHant:
+			err = zhTwTranslations.RegisterDefaultTranslations(v, trans)
+		case JA:
+			err = jaTranslations.RegisterDefaultTranslations(v, trans)
+		default:
+			err = enTranslations.RegisterDefaultTranslations(v, trans)
+		}
+		if err != nil {
+			return err
+		}
 	}
 
-	if err := v.RegisterValidation(onlyTrimTag, onlyTrimSpace); err != nil {
+	if err := registerMessages(u); err != nil {
 		return err
 	}
 
-	zhT := zh.New()
-	enT := en.New()
+	if err := registerCustomTranslations(v, u); err != nil {
+		return err
+	}
 
-	uni := unit.New(enT, zhT, enT)
+	uni.Store(u)
+	return nil
+}
 
-	trans, ok = uni.GetTranslator(string(locale))
-	if !ok {
-		return errors.Errorf("uni.GetTranslator(%s) failed", locale)
+// getTranslator 取指定语言的翻译器，不支持的语言使用默认语言
+func getTranslator(locale Locale) unit.Translator {
+	u := uni.Load()
+	if u == nil {
+		return nil
 	}
-
-	var err error
-	// 注册翻译器
-	switch locale {
-	case ZH:
-		err = zhTranslations.RegisterDefaultTranslations(v, trans)
-	default:
-		err = enTranslations.RegisterDefaultTranslations(v, trans)
+	if trans, ok := u.GetTranslator(string(locale)); ok {
+		return trans
 	}
+	trans, _ := u.GetTranslator(string(DefaultLocale()))
+	return trans
+}
 
-	if err := v.RegisterTranslation(requiredTrimTag, trans,
-		registerTranslator(requiredTrimTag, "{0}为必填字段"),
-		translationFunc); err != nil {
-		return err
+// TransOpt Translate 的选项
+type TransOpt func(*transConfig)
+
+type transConfig struct {
+	locale  Locale
+	rootTyp reflect.Type
+	tagKey  string
+	query   bool // 按 Query 的规则生成路径
+}
+
+// TransWithLocale 指定翻译语言，默认为 DefaultLocale()
+// 在 handler 中通常传入 GetLocale(c)
+func TransWithLocale(locale Locale) TransOpt {
+	return func(cfg *transConfig) {
+		cfg.locale = locale
 	}
+}
 
-	return err
+// TransWithBinding 按 obj 的类型及 b 使用的 tag 计算字段路径，如 binding.Query 取 form tag
+// 可正确展开嵌入的结构体，未指定时字段路径由 validator 的 tag-name 函数生成，见 fieldTagName
+// 指定后才能读取 label tag 作为字段显示名；b 可为 nil
+func TransWithBinding(obj any, b binding.Binding) TransOpt {
+	return func(cfg *transConfig) {
+		cfg.rootTyp = reflect.TypeOf(obj)
+		if b != nil {
+			cfg.tagKey = bindingTagKey(b)
+			cfg.query = b == Query
+		}
+	}
+}
 
+// fieldName 字段在请求中的名称，与 JsonPath 的规则一致
+func (p *transConfig) fieldName(fld reflect.StructField) string {
+	if p.query {
+		if name := tagName(fld, "form"); name != "" {
+			return name
+		}
+		return fld.Name
+	}
+	name, _ := decoderFieldName(fld, p.tagKey)
+	return name
 }
 
-func Translate(errs validator.ValidationErrors) map[string]string {
-	return removeTopStruct(errs.Translate(trans))
+func newTransConfig(opt []TransOpt) *transConfig {
+	cfg := &transConfig{
+		locale: DefaultLocale(),
+	}
+	for _, v := range opt {
+		v(cfg)
+	}
+	return cfg
 }
 
-func removeTopStruct(fields map[string]string) map[string]string {
+// Translate 翻译校验错误，key 为客户端字段路径，如 items[0].sku_id
+func Translate(errs validator.ValidationErrors, opt ...TransOpt) map[string]string {
 	res := map[string]string{}
-	for field, err := range fields {
-		res[field[strings.Index(field, ".")+1:]] = err
+	for _, v := range TranslateFields(errs, opt...) {
+		res[v.JsonPath] = v.Message
 	}
 	return res
 }
 
-// requiredTrim 去空格后必填
-func requiredTrim(fl validator.FieldLevel) bool {
-	field := fl.Field()
+// FieldError 单个字段的校验错误，供前端将错误定位到输入项
+type FieldError struct {
+	Field    string `json:"field"`    // Go 字段路径，如 Items[0].SkuId
+	JsonPath string `json:"jsonPath"` // 客户端字段路径，如 items[0].sku_id
+	Tag      string `json:"tag"`      // 未通过的校验规则，如 required、max
+	Param    string `json:"param"`    // 规则参数，如 max=10 中的 10
+	Message  string `json:"message"`  // 翻译后的提示
+}
 
-	switch field.Kind() {
-	case reflect.String:
-		v := strings.TrimSpace(field.String())
-		if len(v) == 0 {
-			return false
+// TranslateFields 与 Translate 相同，但以列表形式返回每个字段的详细信息，顺序与 errs 一致
+func TranslateFields(errs validator.ValidationErrors, opt ...TransOpt) []FieldError {
+	cfg := newTransConfig(opt)
+	trans := getTranslator(cfg.locale)
+
+	l := make([]FieldError, 0, len(errs))
+	for _, v := range errs {
+		fe := FieldError{
+			Field:    trimTopStruct(v.StructNamespace()),
+			JsonPath: strings.ReplaceAll(trimTopStruct(v.Namespace()), fieldNameMark, ""),
+			Tag:      v.Tag(),
+			Param:    v.Param(),
+		}
+		var fld *reflect.StructField
+		if cfg.rootTyp != nil {
+			if cfg.query {
+				fe.JsonPath = queryPath(cfg.rootTyp, fe.Field)
+			} else {
+				fe.JsonPath = clientPath(cfg.rootTyp, fe.Field, cfg.tagKey)
+			}
+			if f, ok := structField(cfg.rootTyp, fe.Field); ok {
+				fld = &f
+			}
 		}
 
-		if fl.Parent().Kind() == reflect.Ptr {
-			field = fl.Parent().Elem().FieldByName(fl.StructFieldName())
-		} else {
-			field = fl.Parent().FieldByName(fl.StructFieldName())
+		// 提示中的 {0} 为 v.Field()，带有 fieldNameMark，可与模板中的其它文字及 {1} 区分
+		// RegisterStructValidation 中 ReportError 报告的字段名不带 fieldNameMark，替换第一次出现的字段名
+		field := v.Field()
+		name := strings.ReplaceAll(field, fieldNameMark, "")
+		fe.Message = v.Error()
+		if trans != nil {
+			// 没有注册翻译的 tag 使用通用提示
+			if msg := v.Translate(trans); msg != v.Error() {
+				fe.Message = msg
+			} else if tpl, ok := fallbackMsgs[Locale(trans.Locale())]; ok {
+				fe.Message = strings.Replace(tpl, "{0}", field, 1)
+			}
 		}
+		if label := fieldLabel(cfg.locale, fld, fe.JsonPath, name); label != "" {
+			fe.Message = strings.Replace(fe.Message, field, label, 1)
+		} else if base, index, ok := strings.Cut(field, fieldNameMark); ok && fld != nil {
+			// 与 JsonPath 一致，字段名按 binding 的规则取，如 toml 没有 toml tag 时为 Go 字段名
+			if n := cfg.fieldName(*fld); n != base {
+				fe.Message = strings.Replace(fe.Message, field, n+index, 1)
+			}
+		}
+		fe.Message = strings.ReplaceAll(fe.Message, fieldNameMark, "")
+		l = append(l, fe)
+	}
+	return l
+}
 
-		field.SetString(v)
+func trimTopStruct(ns string) string {
+	return ns[strings.Index(ns, ".")+1:]
+}
 
-		return true
-	default:
-		return true
-	}
+// requiredTrim 去空格后必填
+func requiredTrim(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	normalizeValue(field, strings.TrimSpace)
 
+	if field.Kind() == reflect.String {
+		return field.Len() > 0
+	}
+	return true
 }
 
 // onlyTrimSpace 仅去空格
 func onlyTrimSpace(fl validator.FieldLevel) bool {
+	normalizeValue(fl.Field(), strings.TrimSpace)
+	return true
+}
+
+// 中国大陆常用格式的校验 tag，只接受 string
+const (
+	cnMobileTag   = "cn_mobile"   // 手机号，11 位，不含 +86
+	cnIdCardTag   = "cn_id_card"  // 18 位居民身份证号，校验出生日期及校验位，末位 X 不区分大小写
+	cnUsccTag     = "cn_uscc"     // 18 位统一社会信用代码，校验校验位
+	bankCardTag   = "bank_card"   // 12 至 19 位银行卡号，Luhn 校验
+	cnPostcodeTag = "cn_postcode" // 6 位邮政编码
+	cnPlateTag    = "cn_plate"    // 车牌号，含新能源车牌，字母须大写，可配合 upper 使用
+)
+
+var (
+	cnMobileRegex   = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	cnIdCardRegex   = regexp.MustCompile(`^[1-9]\d{16}[\dXx]$`)
+	cnUsccRegex     = regexp.MustCompile(`^[0-9A-HJ-NPQRTUWXY]{2}\d{6}[0-9A-HJ-NPQRTUWXY]{10}$`)
+	bankCardRegex   = regexp.MustCompile(`^\d{12,19}$`)
+	cnPostcodeRegex = regexp.MustCompile(`^\d{6}$`)
+	cnPlateRegex    = regexp.MustCompile(`^[京津沪渝冀豫云辽黑湘皖鲁新苏浙赣鄂桂甘晋蒙陕吉闽贵粤青藏川宁琼][A-HJ-NP-Z]` +
+		`(?:[A-HJ-NP-Z0-9]{4}[A-HJ-NP-Z0-9挂学警港澳]|[A-HJ-K][A-HJ-NP-Z0-9]\d{4}|\d{5}[A-HJ-K])$`)
+)
+
+// cnValidations 随 gi 注册的中国大陆格式校验
+var cnValidations = []struct {
+	tag  string
+	fn   validator.Func
+	msgs map[Locale]string
+}{
+	{cnMobileTag, regexValidation(cnMobileRegex), map[Locale]string{
+		ZH:     "{0}必须是有效的手机号码",
+		EN:     "{0} must be a valid mobile number",
+		ZHHant: "{0}必須是有效的手機號碼",
+		JA:     "{0}は有効な携帯電話番号でなければなりません",
+	}},
+	{cnIdCardTag, cnIdCard, map[Locale]string{
+		ZH:     "{0}必须是有效的身份证号码",
+		EN:     "{0} must be a valid resident ID card number",
+		ZHHant: "{0}必須是有效的身分證號碼",
+		JA:     "{0}は有効な身分証番号でなければなりません",
+	}},
+	{cnUsccTag, cnUscc, map[Locale]string{
+		ZH:     "{0}必须是有效的统一社会信用代码",
+		EN:     "{0} must be a valid unified social credit code",
+		ZHHant: "{0}必須是有效的統一社會信用代碼",
+		JA:     "{0}は有効な統一社会信用コードでなければなりません",
+	}},
+	{bankCardTag, bankCard, map[Locale]string{
+		ZH:     "{0}必须是有效的银行卡号",
+		EN:     "{0} must be a valid bank card number",
+		ZHHant: "{0}必須是有效的銀行卡號",
+		JA:     "{0}は有効な銀行カード番号でなければなりません",
+	}},
+	{cnPostcodeTag, regexValidation(cnPostcodeRegex), map[Locale]string{
+		ZH:     "{0}必须是有效的邮政编码",
+		EN:     "{0} must be a valid postal code",
+		ZHHant: "{0}必須是有效的郵遞區號",
+		JA:     "{0}は有効な郵便番号でなければなりません",
+	}},
+	{cnPlateTag, regexValidation(cnPlateRegex), map[Locale]string{
+		ZH:     "{0}必须是有效的车牌号",
+		EN:     "{0} must be a valid license plate number",
+		ZHHant: "{0}必須是有效的車牌號碼",
+		JA:     "{0}は有効なナンバープレートでなければなりません",
+	}},
+}
+
+func regexValidation(re *regexp.Regexp) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		return field.Kind() == reflect.String && re.MatchString(field.String())
+	}
+}
+
+var (
+	idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardChecks  = "10X98765432"
+)
+
+// cnIdCard 身份证号，第 7 至 14 位为出生日期，末位为 ISO 7064 MOD 11-2 校验位
+func cnIdCard(fl validator.FieldLevel) bool {
 	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	s := strings.ToUpper(field.String())
+	if !cnIdCardRegex.MatchString(s) {
+		return false
+	}
 
-	switch field.Kind() {
-	case reflect.String:
-		v := field.String()
-		if len(v) == 0 {
-			return true
-		}
+	if _, err := time.Parse("20060102", s[6:14]); err != nil {
+		return false
+	}
 
-		if fl.Parent().Kind() == reflect.Ptr {
-			field = fl.Parent().Elem().FieldByName(fl.StructFieldName())
-		} else {
-			field = fl.Parent().FieldByName(fl.StructFieldName())
-		}
+	sum := 0
+	for i, w := range idCardWeights {
+		sum += int(s[i]-'0') * w
+	}
+	return s[17] == idCardChecks[sum%11]
+}
 
-		field.SetString(strings.TrimSpace(field.String()))
+var (
+	usccChars   = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+	usccWeights = []int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+)
 
+// cnUscc 统一社会信用代码，末位为 GB 32100 规定的 MOD 31 校验位
+func cnUscc(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	s := field.String()
+	if !cnUsccRegex.MatchString(s) {
+		return false
 	}
 
-	return true
+	sum := 0
+	for i, w := range usccWeights {
+		sum += strings.IndexByte(usccChars, s[i]) * w
+	}
+	return s[17] == usccChars[(31-sum%31)%31]
+}
+
+// bankCard 银行卡号，Luhn 校验
+func bankCard(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	s := field.String()
+	if !bankCardRegex.MatchString(s) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		d := int(s[len(s)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
 }
 
 func registerTranslator(tag string, msg string) validator.RegisterTranslationsFunc {
 	return func(trans unit.Translator) error {
-		if err := trans.Add(tag, msg, false); err != nil {
-			return err
-		}
-		return nil
+		return trans.Add(tag, msg, true)
 	}
 }
 
+// translationFunc {0} 为字段名，{1} 为 tag 参数，没有翻译时返回原始错误
 func translationFunc(trans unit.Translator, fe validator.FieldError) string {
-	msg, err := trans.T(fe.Tag(), fe.Field())
+	msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
 	if err != nil {
-		panic(fe.(error).Error())
+		return fe.Error()
 	}
 	return msg
 }
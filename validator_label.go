@@ -0,0 +1,58 @@
+package gi
+
+import (
+	"reflect"
+	"sync"
+)
+
+const labelTag = "label"
+
+var labels struct {
+	sync.RWMutex
+	m map[Locale]map[string]string
+}
+
+// RegisterLabels 注册指定语言的字段显示名，key 为客户端字段路径（如 items[0].sku_id）或字段名（如 sku_id）
+// 用于多语言场景或无法修改结构体 tag 的情况，可多次调用，同名的以后注册的为准
+func RegisterLabels(locale Locale, m map[string]string) {
+	labels.Lock()
+	defer labels.Unlock()
+	if labels.m == nil {
+		labels.m = map[Locale]map[string]string{}
+	}
+	if labels.m[locale] == nil {
+		labels.m[locale] = map[string]string{}
+	}
+	for k, v := range m {
+		labels.m[locale][k] = v
+	}
+}
+
+func catalogLabel(locale Locale, keys ...string) string {
+	labels.RLock()
+	defer labels.RUnlock()
+	for _, k := range keys {
+		if v := labels.m[locale][k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// fieldLabel 字段在提示中的显示名，没有时返回空字符串
+// 优先级为 label_<locale> tag，如 label_en:"User name"；RegisterLabels 注册的路径、字段名；label tag
+// 结构体 tag 需通过 TransWithBinding 指定结构体类型才能读取
+func fieldLabel(locale Locale, fld *reflect.StructField, path, name string) string {
+	if fld != nil {
+		if v := fld.Tag.Get(labelTag + "_" + string(locale)); v != "" {
+			return v
+		}
+	}
+	if v := catalogLabel(locale, path, name); v != "" {
+		return v
+	}
+	if fld != nil {
+		return fld.Tag.Get(labelTag)
+	}
+	return ""
+}
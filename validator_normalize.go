@@ -0,0 +1,203 @@
+package gi
+
+import (
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	xhtml "golang.org/x/net/html"
+)
+
+// 规范化 tag，校验时直接修改字段的值，总是校验通过，可与其它 tag 组合，按书写顺序执行
+// 如 binding:"trim,lower,email"
+// 支持 string、*string、[]string 及嵌套结构体中的字段，字段须可寻址（即绑定到指针）；map 中的值无法修改，会被忽略
+const (
+	lowerTag        = "lower"         // 转小写，如邮箱
+	upperTag        = "upper"         // 转大写，如邀请码
+	squishTag       = "squish"        // 去掉首尾空白，中间连续的空白合并为一个空格
+	nfkcTag         = "nfkc"          // NFKC 规范化，全角字母、数字、符号转为半角
+	stripHtmlTag    = "strip_html"    // 去掉 HTML 标签及 script、style 中的内容
+	sanitizeHtmlTag = "sanitize_html" // 按白名单清理 HTML，保留常用的格式标签，见 sanitizeHtml
+	digitsOnlyTag   = "digits_only"   // 只保留数字 0-9，全角数字需先经过 nfkc
+)
+
+// normalizeFunc 以 fn 修改字段值的校验函数
+func normalizeFunc(fn func(string) string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		normalizeValue(fl.Field(), fn)
+		return true
+	}
+}
+
+// normalizeValue 修改 v 中的字符串，支持指针及 slice、array
+func normalizeValue(v reflect.Value, fn func(string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(fn(v.String()))
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			normalizeValue(v.Elem(), fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			normalizeValue(v.Index(i), fn)
+		}
+	}
+}
+
+func squish(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// stripHtml 只保留文本，文本中的 &、<、> 重新转义，结果中不会出现标签
+// script、style 中的内容去掉；textarea、title、xmp、iframe 等元素中的内容按文本处理，同样转义
+func stripHtml(s string) string {
+	if !strings.ContainsAny(s, "<>") {
+		return s
+	}
+
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	skip := 0 // 位于 script、style 中
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return b.String()
+		case xhtml.StartTagToken:
+			if name, _ := z.TagName(); isRawTextTag(name) {
+				skip++
+			}
+		case xhtml.EndTagToken:
+			if name, _ := z.TagName(); isRawTextTag(name) && skip > 0 {
+				skip--
+			}
+		case xhtml.TextToken:
+			if skip == 0 {
+				textEscaper.WriteString(&b, string(z.Text()))
+			}
+		}
+	}
+}
+
+// textEscaper 转义文本中可构成标签或实体的字符，引号保持原样
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func isRawTextTag(name []byte) bool {
+	s := string(name)
+	return s == "script" || s == "style"
+}
+
+// sanitizeAllowed 允许保留的标签及各自允许的属性，其它标签去掉但保留其中的文本
+var sanitizeAllowed = map[string][]string{
+	"a": {"href", "title"}, "img": {"src", "alt", "title"},
+	"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil, "del": nil, "sub": nil, "sup": nil,
+	"p": nil, "br": nil, "hr": nil, "span": nil, "div": nil, "blockquote": nil, "code": nil, "pre": nil,
+	"ul": nil, "ol": nil, "li": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+}
+
+// sanitizeUrlAttrs 值为链接的属性，只允许 http、https、mailto 及相对路径
+var sanitizeUrlAttrs = map[string]bool{"href": true, "src": true}
+
+// sanitizeHtml 按白名单清理 HTML：保留 sanitizeAllowed 中的标签及属性，去掉事件属性、javascript: 等链接、
+// 注释及 script、style 中的内容；文本统一重新转义，未闭合的标签在末尾补齐
+// 结果可直接作为 HTML 输出，不应再次转义
+func sanitizeHtml(s string) string {
+	if !strings.ContainsAny(s, "<>&") {
+		return s
+	}
+
+	var (
+		b     strings.Builder
+		open  []string // 已输出尚未闭合的标签
+		skip  int      // 位于 script、style 中
+		z     = xhtml.NewTokenizer(strings.NewReader(s))
+		voids = map[string]bool{"br": true, "hr": true, "img": true}
+	)
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+		case xhtml.TextToken:
+			if skip == 0 {
+				b.WriteString(xhtml.EscapeString(string(z.Text())))
+			}
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if isRawTextTag(name) {
+				if tt == xhtml.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			allowed, ok := sanitizeAllowed[tag]
+			if !ok || skip > 0 || tt == xhtml.SelfClosingTagToken && !voids[tag] {
+				continue
+			}
+
+			b.WriteString("<" + tag)
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if !slices.Contains(allowed, string(key)) || sanitizeUrlAttrs[string(key)] && !isSafeUrl(string(val)) {
+					continue
+				}
+				b.WriteString(" " + string(key) + `="` + xhtml.EscapeString(string(val)) + `"`)
+			}
+			b.WriteString(">")
+			if !voids[tag] {
+				open = append(open, tag)
+			}
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			if isRawTextTag(name) {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			// 闭合最内层的同名标签，其间未闭合的标签一并闭合；没有对应的开始标签时忽略
+			i := len(open) - 1
+			for i >= 0 && open[i] != string(name) {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			for j := len(open) - 1; j >= i; j-- {
+				b.WriteString("</" + open[j] + ">")
+			}
+			open = open[:i]
+		}
+	}
+}
+
+// isSafeUrl 只允许 http、https、mailto 及不带 scheme 的相对路径
+func isSafeUrl(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
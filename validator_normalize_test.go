@@ -0,0 +1,52 @@
+package gi
+
+import "testing"
+
+func TestStripHtml(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"plain & text", "plain & text"},
+		{"<b>hi</b> <script>x</script>there", "hi there"},
+		{"<style>p{}</style>ok", "ok"},
+		{"<p>a &lt;b&gt; &amp; c</p>", "a &lt;b&gt; &amp; c"},
+		{"<<b>script>alert(1)<</b>/script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		// raw text 及 RCDATA 元素中的内容按文本转义
+		{"<textarea><script>alert(1)</script></textarea>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<title><script>alert(1)</script></title>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<xmp><script>alert(1)</script></xmp>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<iframe><script>alert(1)</script></iframe>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<noembed><script>alert(1)</script></noembed>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<noframes><script>alert(1)</script></noframes>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<noscript><script>alert(1)</script></noscript>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"<plaintext><script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+	}
+	for _, tc := range cases {
+		if got := stripHtml(tc.in); got != tc.want {
+			t.Errorf("stripHtml(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeHtml(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"<b>hi</b> <script>x</script>there", "<b>hi</b> there"},
+		{`<a href="javascript:alert(1)" onclick="x">a</a>`, "<a>a</a>"},
+		{`<a href="https://example.com" title="t">a</a>`, `<a href="https://example.com" title="t">a</a>`},
+		{`<img src="/a.png" onerror="x">`, `<img src="/a.png">`},
+		{"<p>unclosed <b>bold", "<p>unclosed <b>bold</b></p>"},
+		{"<textarea><script>alert(1)</script></textarea>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		// 同名标签嵌套时闭合最内层的
+		{"<b><i><b>x</b>y</i>z</b>", "<b><i><b>x</b>y</i>z</b>"},
+		{"<div><div>a</div>b</div>c", "<div><div>a</div>b</div>c"},
+		{"<b><i>x</b>y", "<b><i>x</i></b>y"},
+	}
+	for _, tc := range cases {
+		if got := sanitizeHtml(tc.in); got != tc.want {
+			t.Errorf("sanitizeHtml(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package gi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// fieldTagKeys 校验错误中字段名的取值顺序，都没有时使用 Go 字段名
+var fieldTagKeys = []string{"json", "form", "uri", "header"}
+
+// fieldNameMark 附加在 validator 字段名之后的不可见分隔符（U+2063），翻译后据此定位提示中的字段名并替换为显示名
+const fieldNameMark = "\u2063"
+
+// validatorTagName 注册到 validator 的 tag-name 函数，字段名按 fieldTagName 取，没有时为 Go 字段名，末尾附加 fieldNameMark
+func validatorTagName(fld reflect.StructField) string {
+	name := fieldTagName(fld)
+	if name == "" {
+		name = fld.Name
+	}
+	return name + fieldNameMark
+}
+
+// fieldTagName 使校验错误中的字段名与客户端一致
+// 不知道具体 binding 时按 json、form、uri、header 的顺序取第一个有效的 tag
+func fieldTagName(fld reflect.StructField) string {
+	for _, key := range fieldTagKeys {
+		if name := tagName(fld, key); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// tagName 取 tag 中的名称部分，忽略 omitempty 等选项，"-" 视为未设置
+func tagName(fld reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(fld.Tag.Get(key), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// namedBinding binding.Binding、binding.BindingUri 等的共同部分
+type namedBinding interface {
+	Name() string
+}
+
+// bindingTagKey binding 解析字段时使用的 tag，未知的 binding 返回空字符串
+func bindingTagKey(b namedBinding) string {
+	switch b.Name() {
+	case "json", "uri", "header", "xml", "yaml", "toml":
+		return b.Name()
+	case "form", "query", "form-urlencoded", "multipart/form-data":
+		return "form"
+	case "msgpack":
+		return "codec"
+	case "protobuf":
+		// protoc-gen-go 生成的 json tag 为 proto 字段名
+		return "json"
+	}
+	return ""
+}
+
+// clientPath 将去掉顶层结构体的 Go 字段路径（如 Items[0].SkuId）转换为客户端路径（如 items[0].sku_id）
+// 字段名按 tagKey 对应解码器的规则取，见 decoderFieldName；被展开的匿名（嵌入）结构体字段不出现在路径中
+func clientPath(t reflect.Type, structNs string, tagKey string) string {
+	var segs []string
+	for _, seg := range strings.Split(structNs, ".") {
+		name, index, _ := strings.Cut(seg, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		t = indirectType(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			segs = append(segs, seg)
+			t = nil
+			continue
+		}
+
+		fld, ok := t.FieldByName(name)
+		if !ok {
+			segs = append(segs, seg)
+			t = nil
+			continue
+		}
+
+		alias, inline := decoderFieldName(fld, tagKey)
+
+		t = fld.Type
+		for i := strings.Count(index, "["); i > 0; i-- {
+			t = elemType(t)
+		}
+
+		if inline && index == "" {
+			continue
+		}
+		segs = append(segs, alias+index)
+	}
+	return strings.Join(segs, ".")
+}
+
+// decoderFieldName 按解码器的规则取字段在请求中的名称，inline 表示匿名字段被展开到上一级
+//   - toml：go-toml/v2 只读 toml tag，没有时为 Go 字段名（解码时不区分大小写）
+//   - yaml：goccy/go-yaml 依次读 yaml、json tag，没有时为小写的 Go 字段名，只有 inline 选项时展开
+//   - codec：msgpack 依次读 codec、json tag，没有时为 Go 字段名
+//   - 其他：先读 tagKey 对应的 tag，再按 fieldTagName 的规则取，与 encoding/json 一致
+//
+// 除 yaml 外，没有 tag 名称的匿名字段都会被展开
+func decoderFieldName(fld reflect.StructField, tagKey string) (name string, inline bool) {
+	switch tagKey {
+	case "toml":
+		name = tagName(fld, "toml")
+	case "yaml":
+		tag := fld.Tag.Get("yaml")
+		if tag == "" {
+			tag = fld.Tag.Get("json")
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			return "", true
+		}
+		if name == "" || name == "-" {
+			name = strings.ToLower(fld.Name)
+		}
+		return name, false
+	case "codec":
+		if name = tagName(fld, "codec"); name == "" {
+			name = tagName(fld, "json")
+		}
+	default:
+		if tagKey != "" {
+			name = tagName(fld, tagKey)
+		}
+		if name == "" {
+			name = fieldTagName(fld)
+		}
+	}
+
+	if name == "" {
+		return fld.Name, fld.Anonymous
+	}
+	return name, false
+}
+
+// queryPath 按 Query 的规则生成客户端路径：没有 form tag 的结构体字段被展开，下级字段以方括号表示，如 filter[status]
+func queryPath(t reflect.Type, structNs string) string {
+	var b strings.Builder
+	write := func(name, index string) {
+		if b.Len() == 0 {
+			b.WriteString(name)
+		} else {
+			b.WriteString("[" + name + "]")
+		}
+		b.WriteString(index)
+	}
+
+	for _, seg := range strings.Split(structNs, ".") {
+		name, index, _ := strings.Cut(seg, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		var (
+			fld reflect.StructField
+			ok  bool
+		)
+		if t = indirectType(t); t != nil && t.Kind() == reflect.Struct {
+			fld, ok = t.FieldByName(name)
+		}
+		if !ok {
+			write(name, index)
+			t = nil
+			continue
+		}
+
+		alias := tagName(fld, "form")
+		t = fld.Type
+		for i := strings.Count(index, "["); i > 0; i-- {
+			t = elemType(t)
+		}
+
+		if ft := indirectType(fld.Type); alias == "" && index == "" && ft.Kind() == reflect.Struct && !isQueryScalar(ft) {
+			continue
+		}
+		if alias == "" {
+			alias = name
+		}
+		write(alias, index)
+	}
+	return b.String()
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// elemType slice、array、map 的元素类型
+func elemType(t reflect.Type) reflect.Type {
+	t = indirectType(t)
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem()
+	}
+	return nil
+}
+
+// structField 按去掉顶层结构体的 Go 字段路径查找字段，路径中的下标及 map key 取元素类型
+func structField(t reflect.Type, structNs string) (reflect.StructField, bool) {
+	var fld reflect.StructField
+	for _, seg := range strings.Split(structNs, ".") {
+		name, _, _ := strings.Cut(seg, "[")
+
+		t = indirectType(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+
+		var ok bool
+		if fld, ok = t.FieldByName(name); !ok {
+			return reflect.StructField{}, false
+		}
+
+		t = fld.Type
+		for i := strings.Count(seg, "["); i > 0; i-- {
+			t = elemType(t)
+		}
+	}
+	return fld, true
+}
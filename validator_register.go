@@ -0,0 +1,191 @@
+package gi
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin/binding"
+	unit "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/unicode/norm"
+)
+
+// fallbackMsgs 自定义 tag 缺少某个语言的翻译时使用的提示
+var fallbackMsgs = map[Locale]string{
+	ZH:     "{0}不符合要求",
+	EN:     "{0} is invalid",
+	ZHHant: "{0}不符合要求",
+	JA:     "{0}が無効です",
+}
+
+type customValidation struct {
+	tag            string
+	fn             validator.Func
+	callEvenIfNull bool
+}
+
+type customStruct struct {
+	fn    validator.StructLevelFunc
+	types []any
+}
+
+type customAlias struct {
+	alias string
+	tags  string
+}
+
+type customTranslation struct {
+	tag  string
+	msgs map[Locale]string
+}
+
+// registry 自定义的校验规则及翻译，New 重建 validator 时重新应用
+var registry = struct {
+	sync.Mutex
+	validations  []customValidation
+	structs      []customStruct
+	aliases      []customAlias
+	translations []customTranslation
+}{
+	validations: []customValidation{
+		{tag: requiredTrimTag, fn: requiredTrim},
+		{tag: onlyTrimTag, fn: onlyTrimSpace, callEvenIfNull: true},
+		{tag: lowerTag, fn: normalizeFunc(strings.ToLower), callEvenIfNull: true},
+		{tag: upperTag, fn: normalizeFunc(strings.ToUpper), callEvenIfNull: true},
+		{tag: squishTag, fn: normalizeFunc(squish), callEvenIfNull: true},
+		{tag: nfkcTag, fn: normalizeFunc(norm.NFKC.String), callEvenIfNull: true},
+		{tag: stripHtmlTag, fn: normalizeFunc(stripHtml), callEvenIfNull: true},
+		{tag: sanitizeHtmlTag, fn: normalizeFunc(sanitizeHtml), callEvenIfNull: true},
+		{tag: digitsOnlyTag, fn: normalizeFunc(digitsOnly), callEvenIfNull: true},
+	},
+	translations: []customTranslation{
+		{tag: requiredTrimTag, msgs: requiredTrimMsgs},
+	},
+}
+
+func init() {
+	for _, v := range cnValidations {
+		registry.validations = append(registry.validations, customValidation{tag: v.tag, fn: v.fn})
+		registry.translations = append(registry.translations, customTranslation{tag: v.tag, msgs: v.msgs})
+	}
+}
+
+// RegisterValidation 注册自定义校验 tag 及各语言的提示，应在启动时调用
+// 提示中 {0} 为字段名（有 label 时为显示名），{1} 为 tag 参数；缺少某个语言时使用通用提示
+// 如 RegisterValidation("even", isEven, map[Locale]string{ZH: "{0}必须为偶数", EN: "{0} must be even"})
+func RegisterValidation(tag string, fn validator.Func, translations map[Locale]string, callValidationEvenIfNull ...bool) error {
+	cv := customValidation{tag: tag, fn: fn, callEvenIfNull: len(callValidationEvenIfNull) > 0 && callValidationEvenIfNull[0]}
+	if v := currentEngine(); v != nil {
+		if err := v.RegisterValidation(cv.tag, cv.fn, cv.callEvenIfNull); err != nil {
+			return errors.Wrapf(err, "register validation %s", tag)
+		}
+	}
+
+	registry.Lock()
+	registry.validations = append(registry.validations, cv)
+	registry.Unlock()
+
+	return RegisterTranslation(tag, translations)
+}
+
+// RegisterStructValidation 注册结构体级别的校验，适用于跨字段的规则，应在启动时调用
+// fn 中通过 sl.ReportError 报告的 tag 可用 RegisterTranslation 注册提示
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	if v := currentEngine(); v != nil {
+		v.RegisterStructValidation(fn, types...)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.structs = append(registry.structs, customStruct{fn: fn, types: types})
+}
+
+// RegisterAlias 注册 tag 别名，如 RegisterAlias("nickname", "required,min=2,max=20", msgs)，应在启动时调用
+// 校验失败时报告的 tag 为别名，提示使用 translations
+func RegisterAlias(alias, tags string, translations map[Locale]string) error {
+	if v := currentEngine(); v != nil {
+		v.RegisterAlias(alias, tags)
+	}
+
+	registry.Lock()
+	registry.aliases = append(registry.aliases, customAlias{alias: alias, tags: tags})
+	registry.Unlock()
+
+	return RegisterTranslation(alias, translations)
+}
+
+// RegisterTranslation 注册或覆盖 tag 的提示，也可用于修改内置 tag 的提示，如 required
+func RegisterTranslation(tag string, translations map[Locale]string) error {
+	ct := customTranslation{tag: tag, msgs: translations}
+
+	registry.Lock()
+	registry.translations = append(registry.translations, ct)
+	registry.Unlock()
+
+	v, u := currentEngine(), uni.Load()
+	if v == nil || u == nil {
+		return nil
+	}
+	return registerCustomTranslation(v, u, ct)
+}
+
+// currentEngine 当前 binding 使用的 validator，gin 默认的及 defaultValidator 都是 *validator.Validate
+func currentEngine() *validator.Validate {
+	if binding.Validator == nil {
+		return nil
+	}
+	v, _ := binding.Validator.Engine().(*validator.Validate)
+	return v
+}
+
+// applyValidations 将注册的校验规则应用到新建的 validator
+func applyValidations(v *validator.Validate) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, cv := range registry.validations {
+		if err := v.RegisterValidation(cv.tag, cv.fn, cv.callEvenIfNull); err != nil {
+			log.WithError(err).WithField("tag", cv.tag).Errorln("register validation")
+		}
+	}
+	for _, cs := range registry.structs {
+		v.RegisterStructValidation(cs.fn, cs.types...)
+	}
+	for _, ca := range registry.aliases {
+		v.RegisterAlias(ca.alias, ca.tags)
+	}
+}
+
+// registerCustomTranslations 在默认翻译之后注册全部自定义提示，同一 tag 以后注册的为准
+func registerCustomTranslations(v *validator.Validate, u *unit.UniversalTranslator) error {
+	registry.Lock()
+	l := append([]customTranslation(nil), registry.translations...)
+	registry.Unlock()
+
+	for _, ct := range l {
+		if err := registerCustomTranslation(v, u, ct); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerCustomTranslation(v *validator.Validate, u *unit.UniversalTranslator, ct customTranslation) error {
+	for _, locale := range supportedLocales {
+		trans, ok := u.GetTranslator(string(locale))
+		if !ok {
+			continue
+		}
+
+		msg := ct.msgs[locale]
+		if msg == "" {
+			msg = fallbackMsgs[locale]
+		}
+		if err := v.RegisterTranslation(ct.tag, trans, registerTranslator(ct.tag, msg), translationFunc); err != nil {
+			return errors.Wrapf(err, "register translation %s for %s", ct.tag, locale)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package gi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestCnValidations(t *testing.T) {
+	v := validator.New()
+	for _, cv := range cnValidations {
+		if err := v.RegisterValidation(cv.tag, cv.fn); err != nil {
+			t.Fatalf("register %s: %v", cv.tag, err)
+		}
+	}
+
+	cases := []struct {
+		tag   string
+		value string
+		valid bool
+	}{
+		{cnMobileTag, "13800138000", true},
+		{cnMobileTag, "19912345678", true},
+		{cnMobileTag, "12345678901", false}, // 第二位不能为 2
+		{cnMobileTag, "1380013800", false},
+		{cnMobileTag, "+8613800138000", false},
+		{cnMobileTag, "1380013800a", false},
+
+		{cnIdCardTag, "11010519491231002X", true},
+		{cnIdCardTag, "11010519491231002x", true},
+		{cnIdCardTag, "440304200001011239", true},
+		{cnIdCardTag, "110105194912310021", false}, // 校验位错误
+		{cnIdCardTag, "110105194902300020", false}, // 2 月 30 日，校验位正确
+		{cnIdCardTag, "110105194913010029", false}, // 13 月，校验位正确
+		{cnIdCardTag, "01010519491231002X", false},
+		{cnIdCardTag, "1101051949123100", false},
+
+		{cnUsccTag, "91350100M000100Y43", true},
+		{cnUsccTag, "91110000600037341L", true},
+		{cnUsccTag, "91350100M000100Y44", false}, // 校验位错误
+		{cnUsccTag, "91350100M000100I43", false}, // 不允许 I
+		{cnUsccTag, "91350100M000100Y4", false},
+
+		{bankCardTag, "6222021234567890128", true},
+		{bankCardTag, "4111111111111111", true},
+		{bankCardTag, "6222021234567890127", false}, // Luhn 错误
+		{bankCardTag, "4111111111111112", false},
+		{bankCardTag, "41111111111", false}, // 少于 12 位
+		{bankCardTag, "6222 0212 3456 7890 128", false},
+
+		{cnPostcodeTag, "100000", true},
+		{cnPostcodeTag, "518000", true},
+		{cnPostcodeTag, "10000", false},
+		{cnPostcodeTag, "1000000", false},
+		{cnPostcodeTag, "10000a", false},
+
+		{cnPlateTag, "京A12345", true},
+		{cnPlateTag, "粤B8K3X9", true},
+		{cnPlateTag, "沪C1234学", true},
+		{cnPlateTag, "京AD12345", true}, // 新能源小型车
+		{cnPlateTag, "粤BFA1234", true},
+		{cnPlateTag, "京A12345D", true}, // 新能源大型车
+		{cnPlateTag, "京a12345", false},
+		{cnPlateTag, "京AI2345", false}, // 不允许 I
+		{cnPlateTag, "京A1234", false},
+		{cnPlateTag, "京AZ12345", false}, // 新能源车牌第三位只能为 A-K
+		{cnPlateTag, "A12345", false},
+	}
+
+	for _, tc := range cases {
+		err := v.Var(tc.value, tc.tag)
+		if got := err == nil; got != tc.valid {
+			t.Errorf("%s %q: valid = %v, want %v", tc.tag, tc.value, got, tc.valid)
+		}
+	}
+}
+
+func TestTranslateFieldsLabel(t *testing.T) {
+	New()
+	even := func(fl validator.FieldLevel) bool { return fl.Field().Int()%2 == 0 }
+	if err := RegisterValidation("test_even", even, map[Locale]string{EN: "value of {0} must be even"}); err != nil {
+		t.Fatal(err)
+	}
+
+	type req struct {
+		Value int    `json:"value" binding:"test_even" label:"Amount"`
+		Start string `json:"start"`
+		End   string `json:"Start" binding:"eqfield=Start" label:"End"`
+		Name  string `json:"name" binding:"required"`
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		// 模板中 {0} 之前出现与字段名相同的文字
+		{"value", "value of Amount must be even"},
+		// 字段名与 {1} 相同
+		{"Start", "End must be equal to Start"},
+		// 没有 label 时保持字段名
+		{"name", "name is a required field"},
+	}
+
+	obj := &req{Value: 1, Start: "a", End: "b"}
+	err := currentEngine().Struct(obj)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Struct: %v", err)
+	}
+	got := Translate(verrs, TransWithLocale(EN), TransWithBinding(obj, nil))
+	for _, tc := range cases {
+		if got[tc.path] != tc.want {
+			t.Errorf("%s: %q, want %q", tc.path, got[tc.path], tc.want)
+		}
+	}
+	for _, v := range TranslateFields(verrs, TransWithLocale(EN)) {
+		if strings.Contains(v.JsonPath+v.Message, fieldNameMark) {
+			t.Errorf("%s: mark not removed: %q", v.JsonPath, v.Message)
+		}
+	}
+}
+
+func TestTranslateFieldsBindingName(t *testing.T) {
+	New()
+
+	type item struct {
+		SkuId int `json:"sku_id" binding:"required"`
+	}
+	type req struct {
+		UserName string `json:"user_name" binding:"required"`
+		Items    []item `json:"items" binding:"dive"`
+	}
+
+	cases := []struct {
+		b        binding.Binding
+		path     string
+		wantPath string
+		want     string
+	}{
+		{binding.JSON, "UserName", "user_name", "user_name is a required field"},
+		{binding.TOML, "UserName", "UserName", "UserName is a required field"},
+		{binding.TOML, "Items[0].SkuId", "Items[0].SkuId", "SkuId is a required field"},
+		{binding.YAML, "UserName", "user_name", "user_name is a required field"},
+		{binding.MsgPack, "Items[0].SkuId", "items[0].sku_id", "sku_id is a required field"},
+	}
+
+	obj := &req{Items: []item{{}}}
+	var verrs validator.ValidationErrors
+	if !errors.As(currentEngine().Struct(obj), &verrs) {
+		t.Fatal("want validation errors")
+	}
+	for _, tc := range cases {
+		for _, v := range TranslateFields(verrs, TransWithLocale(EN), TransWithBinding(obj, tc.b)) {
+			if v.Field != tc.path {
+				continue
+			}
+			if v.JsonPath != tc.wantPath || v.Message != tc.want {
+				t.Errorf("%s %s: %q %q, want %q %q", tc.b.Name(), tc.path, v.JsonPath, v.Message, tc.wantPath, tc.want)
+			}
+		}
+	}
+}